@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/lilo/backend/config"
+	"github.com/lilo/backend/internal/domain"
 	"github.com/lilo/backend/internal/handler"
 	"github.com/lilo/backend/internal/repository"
 	"github.com/lilo/backend/internal/service"
@@ -28,32 +30,131 @@ func main() {
 	}
 
 	// Initialize repositories
-	userRepo := repository.NewUserRepository()
-	wardrobeRepo := repository.NewWardrobeRepository()
-	outfitRepo := repository.NewOutfitRepository()
+	searchIndex := repository.NewSearchIndex()
+	changeLog := repository.NewChangeLog()
+	userRepo := repository.NewTrackedUserRepository(repository.NewUserRepository(), changeLog)
+	wardrobeRepo := repository.NewTrackedWardrobeRepository(
+		repository.NewIndexedWardrobeRepository(repository.NewWardrobeRepository(), searchIndex), changeLog)
+	outfitRepo := repository.NewTrackedOutfitRepository(
+		repository.NewIndexedOutfitRepository(repository.NewOutfitRepository(), searchIndex), changeLog)
 	recommendationRepo := repository.NewRecommendationRepository()
+	idempotencyStore := repository.NewIdempotencyStore()
+	deletionRepo := repository.NewDeletionRepository()
+	auditLog := repository.NewAuditLog()
+	apiKeyRepo := repository.NewAPIKeyRepository()
+	sessionRepo := repository.NewSessionRepository()
+	rateLimitStore := repository.NewRateLimitStore()
+
+	// Images live in S3 when it's enabled; otherwise every image URL is external
+	storageConfig := config.GetStorageConfig()
+	imageStore := repository.NewNoImageStore()
+	corsConfig := config.GetCorsConfig()
+	if storageConfig.S3Enabled {
+		awsConfig, err := config.InitAWS()
+		if err != nil {
+			logger.Fatalf("Error initializing AWS: %v", err)
+		}
+		if err := config.CreateS3Buckets(awsConfig.S3Client, awsConfig.Region, corsConfig); err != nil {
+			logger.Fatalf("Error setting up S3 buckets: %v", err)
+		}
+		imageStore = repository.NewS3ImageStore(awsConfig.S3Client, config.ImageS3Buckets, storageConfig.SignedURLTTL)
+	}
 
 	// Initialize services
 	userService := service.NewUserService(userRepo)
 	wardrobeService := service.NewWardrobeService(wardrobeRepo)
-	outfitService := service.NewOutfitService(outfitRepo)
-	recommendationService := service.NewRecommendationService(recommendationRepo, wardrobeRepo, outfitRepo)
+	outfitService := service.NewOutfitService(outfitRepo, wardrobeRepo)
+	recommendationService := service.NewRecommendationService(recommendationRepo, wardrobeRepo, outfitRepo, userRepo, wardrobeService)
+	analyticsService := service.NewAnalyticsService(wardrobeRepo)
+	searchService := service.NewSearchService(searchIndex, wardrobeRepo, outfitRepo)
+	syncService := service.NewSyncService(changeLog, userService, wardrobeService, outfitService)
+	exportService := service.NewExportService(wardrobeService, outfitService)
+	accountService := service.NewAccountService(userRepo, wardrobeRepo, outfitRepo, recommendationRepo,
+		changeLog, idempotencyStore, apiKeyRepo, sessionRepo, deletionRepo, auditLog, imageStore, config.GetAccountConfig().DeletionGracePeriod)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	sessionService, err := service.NewSessionService(sessionRepo, userRepo)
+	if err != nil {
+		logger.Fatalf("Error initializing sessions: %v", err)
+	}
+	adminService := service.NewAdminService(userRepo, wardrobeRepo, wardrobeService, auditLog)
+	authWebhookService := service.NewAuthWebhookService(userService, accountService, 2*middleware.WebhookTolerance)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
 	wardrobeHandler := handler.NewWardrobeHandler(wardrobeService)
 	outfitHandler := handler.NewOutfitHandler(outfitService)
 	recommendationHandler := handler.NewRecommendationHandler(recommendationService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	syncHandler := handler.NewSyncHandler(syncService)
+	exportHandler := handler.NewExportHandler(exportService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	adminHandler := handler.NewAdminHandler(adminService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	webhookHandler := handler.NewWebhookHandler(authWebhookService)
 
 	// Initialize router
 	router := http.NewServeMux()
 
 	supabaseConfig := config.GetSupabaseConfig()
+	idempotencyConfig := config.GetIdempotencyConfig()
+	rateLimitConfig := config.GetRateLimitConfig()
+
+	// Tokens signed with asymmetric keys are checked against the project's JWKS
+	verifierConfig := middleware.TokenVerifierConfig{
+		HMACSecret: supabaseConfig.JWTSecret,
+		Issuer:     supabaseConfig.JWTIssuer,
+		Audience:   supabaseConfig.JWTAudience,
+	}
+	if supabaseConfig.JWKSURL != "" {
+		verifierConfig.Keys = middleware.NewJWKSCache(middleware.NewHTTPJWKSFetcher(supabaseConfig.JWKSURL), 0, 0)
+	}
+	verifier := middleware.NewTokenVerifier(verifierConfig)
 
 	// Apply middleware
-	corsMiddleware := middleware.CorsMiddleware
+	corsMiddleware := middleware.CorsMiddleware(middleware.CorsConfig{
+		AllowedOrigins:   corsConfig.AllowedOrigins,
+		AllowedMethods:   corsConfig.AllowedMethods,
+		AllowedHeaders:   corsConfig.AllowedHeaders,
+		ExposedHeaders:   corsConfig.ExposedHeaders,
+		AllowCredentials: corsConfig.AllowCredentials,
+		MaxAge:           corsConfig.MaxAge,
+	}, router)
 	loggingMiddleware := middleware.LoggingMiddleware(logger)
-	authMiddleware := middleware.AuthMiddleware(userService, supabaseConfig.JWTSecret)
+	authenticate := middleware.AuthMiddleware(userService, verifier, apiKeyService, sessionService)
+	idempotency := middleware.IdempotencyMiddleware(idempotencyStore, idempotencyConfig.TTL)
+	// Stored images are private, so responses carry signed URLs for them
+	images := func(next http.Handler) http.Handler { return next }
+	if storageConfig.S3Enabled {
+		images = middleware.ImageURLMiddleware(imageStore)
+	}
+	rateLimit := func(group string, limit config.RateLimit) func(http.Handler) http.Handler {
+		return middleware.RateLimitMiddleware(rateLimitStore, group,
+			domain.RateLimit{Requests: limit.Requests, Window: limit.Window})
+	}
+	ipRateLimit := rateLimit("ip", rateLimitConfig.IP)
+	defaultRateLimit := rateLimit("default", rateLimitConfig.Default)
+	uploadRateLimit := rateLimit("uploads", rateLimitConfig.Uploads)
+	recommendationsRateLimit := rateLimit("recommendations", rateLimitConfig.Recommendations)
+	// Expensive routes are held to their group's limit on top of the default one
+	limited := func(limit func(http.Handler) http.Handler, next http.HandlerFunc) http.HandlerFunc {
+		return limit(next).ServeHTTP
+	}
+	// Idempotency keys and rate limits are per user, so they're checked once
+	// the user is known; the IP limit comes first so failed sign-ins are
+	// limited too. Routes are for signed-in sessions only unless they name the
+	// scopes an API key needs to use them.
+	authMiddleware := func(next http.Handler) http.Handler {
+		return ipRateLimit(authenticate(defaultRateLimit(images(middleware.RequireSession(idempotency(next))))))
+	}
+	scopedMiddleware := func(next http.HandlerFunc, scopes ...domain.Scope) http.Handler {
+		return ipRateLimit(authenticate(defaultRateLimit(images(middleware.RequireScope(scopes...)(idempotency(next))))))
+	}
+	// Admin routes additionally require a permission granted by the user's role
+	adminMiddleware := func(permission domain.Permission, next http.HandlerFunc) http.Handler {
+		return authMiddleware(middleware.RequirePermission(permission)(next))
+	}
 
 	// Register routes
 	router.HandleFunc("GET /api/health", func(w http.ResponseWriter, r *http.Request) {
@@ -61,35 +162,93 @@ func main() {
 	})
 
 	// User routes
-	router.HandleFunc("POST /api/auth/signup", userHandler.SignUp)
-	router.HandleFunc("POST /api/auth/signin", userHandler.SignIn)
-	router.HandleFunc("POST /api/auth/signout", userHandler.SignOut)
-	router.Handle("GET /api/auth/user", authMiddleware(http.HandlerFunc(userHandler.GetUser)))
-	router.Handle("PUT /api/users/profile", authMiddleware(http.HandlerFunc(userHandler.UpdateProfile)))
-	router.Handle("GET /api/users/style-profile", authMiddleware(http.HandlerFunc(userHandler.GetStyleProfile)))
-	router.Handle("PUT /api/users/style-profile", authMiddleware(http.HandlerFunc(userHandler.UpdateStyleProfile)))
+	router.Handle("POST /api/auth/signup", authMiddleware(http.HandlerFunc(userHandler.SignUp)))
+	router.Handle("POST /api/auth/signout", authMiddleware(http.HandlerFunc(sessionHandler.SignOut)))
+	router.Handle("GET /api/auth/sessions", authMiddleware(http.HandlerFunc(sessionHandler.ListSessions)))
+	router.Handle("DELETE /api/auth/sessions", authMiddleware(http.HandlerFunc(sessionHandler.RevokeAllSessions)))
+	router.Handle("DELETE /api/auth/sessions/{id}", authMiddleware(http.HandlerFunc(sessionHandler.RevokeSession)))
+	router.Handle("GET /api/auth/user", scopedMiddleware(userHandler.GetUser, domain.ScopeProfileRead))
+	router.Handle("PUT /api/users/profile", scopedMiddleware(userHandler.UpdateProfile, domain.ScopeProfileWrite))
+	router.Handle("PATCH /api/users/profile", scopedMiddleware(userHandler.PatchProfile, domain.ScopeProfileWrite))
+	router.Handle("GET /api/users/style-profile", scopedMiddleware(userHandler.GetStyleProfile, domain.ScopeProfileRead))
+	router.Handle("PUT /api/users/style-profile", scopedMiddleware(userHandler.UpdateStyleProfile, domain.ScopeProfileWrite))
+	router.Handle("PATCH /api/users/style-profile", scopedMiddleware(userHandler.PatchStyleProfile, domain.ScopeProfileWrite))
 
 	// Wardrobe routes
-	router.Handle("GET /api/wardrobe/items", authMiddleware(http.HandlerFunc(wardrobeHandler.GetItems)))
-	router.Handle("POST /api/wardrobe/items", authMiddleware(http.HandlerFunc(wardrobeHandler.AddItem)))
-	router.Handle("GET /api/wardrobe/items/{id}", authMiddleware(http.HandlerFunc(wardrobeHandler.GetItem)))
-	router.Handle("PUT /api/wardrobe/items/{id}", authMiddleware(http.HandlerFunc(wardrobeHandler.UpdateItem)))
-	router.Handle("DELETE /api/wardrobe/items/{id}", authMiddleware(http.HandlerFunc(wardrobeHandler.DeleteItem)))
-	router.Handle("GET /api/wardrobe/categories", authMiddleware(http.HandlerFunc(wardrobeHandler.GetCategories)))
+	router.Handle("GET /api/wardrobe/items", scopedMiddleware(wardrobeHandler.GetItems, domain.ScopeWardrobeRead))
+	router.Handle("POST /api/wardrobe/items", scopedMiddleware(wardrobeHandler.AddItem, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/items:batch", scopedMiddleware(wardrobeHandler.BatchAddItems, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/items:batchUpdate", scopedMiddleware(wardrobeHandler.BatchUpdateItems, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/items:batchDelete", scopedMiddleware(wardrobeHandler.BatchDeleteItems, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/import", scopedMiddleware(limited(uploadRateLimit, wardrobeHandler.ImportItems), domain.ScopeWardrobeWrite))
+	router.Handle("GET /api/wardrobe/items/{id}", scopedMiddleware(wardrobeHandler.GetItem, domain.ScopeWardrobeRead))
+	router.Handle("PUT /api/wardrobe/items/{id}", scopedMiddleware(wardrobeHandler.UpdateItem, domain.ScopeWardrobeWrite))
+	router.Handle("PATCH /api/wardrobe/items/{id}", scopedMiddleware(wardrobeHandler.PatchItem, domain.ScopeWardrobeWrite))
+	router.Handle("DELETE /api/wardrobe/items/{id}", scopedMiddleware(wardrobeHandler.DeleteItem, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/items/{id}/status", scopedMiddleware(wardrobeHandler.ChangeItemStatus, domain.ScopeWardrobeWrite))
+	router.Handle("GET /api/wardrobe/categories", scopedMiddleware(wardrobeHandler.GetCategories, domain.ScopeWardrobeRead))
+	router.Handle("POST /api/wardrobe/categories", scopedMiddleware(wardrobeHandler.AddCategory, domain.ScopeWardrobeWrite))
+	router.Handle("PUT /api/wardrobe/categories/{id}", scopedMiddleware(wardrobeHandler.RenameCategory, domain.ScopeWardrobeWrite))
+	router.Handle("POST /api/wardrobe/categories/{id}/subcategories", scopedMiddleware(wardrobeHandler.AddSubcategory, domain.ScopeWardrobeWrite))
+	router.Handle("GET /api/wardrobe/analytics", scopedMiddleware(analyticsHandler.GetWardrobeAnalytics, domain.ScopeWardrobeRead))
 
 	// Outfit routes
-	router.Handle("GET /api/outfits", authMiddleware(http.HandlerFunc(outfitHandler.GetOutfits)))
-	router.Handle("POST /api/outfits", authMiddleware(http.HandlerFunc(outfitHandler.CreateOutfit)))
-	router.Handle("GET /api/outfits/{id}", authMiddleware(http.HandlerFunc(outfitHandler.GetOutfit)))
-	router.Handle("PUT /api/outfits/{id}", authMiddleware(http.HandlerFunc(outfitHandler.UpdateOutfit)))
-	router.Handle("DELETE /api/outfits/{id}", authMiddleware(http.HandlerFunc(outfitHandler.DeleteOutfit)))
-	router.Handle("POST /api/outfits/{id}/favorite", authMiddleware(http.HandlerFunc(outfitHandler.FavoriteOutfit)))
-	router.Handle("DELETE /api/outfits/{id}/favorite", authMiddleware(http.HandlerFunc(outfitHandler.UnfavoriteOutfit)))
+	router.Handle("GET /api/outfits", scopedMiddleware(outfitHandler.GetOutfits, domain.ScopeOutfitsRead))
+	router.Handle("POST /api/outfits", scopedMiddleware(outfitHandler.CreateOutfit, domain.ScopeOutfitsWrite))
+	router.Handle("GET /api/outfits/{id}", scopedMiddleware(outfitHandler.GetOutfit, domain.ScopeOutfitsRead))
+	router.Handle("PUT /api/outfits/{id}", scopedMiddleware(outfitHandler.UpdateOutfit, domain.ScopeOutfitsWrite))
+	router.Handle("PATCH /api/outfits/{id}", scopedMiddleware(outfitHandler.PatchOutfit, domain.ScopeOutfitsWrite))
+	router.Handle("DELETE /api/outfits/{id}", scopedMiddleware(outfitHandler.DeleteOutfit, domain.ScopeOutfitsWrite))
+	router.Handle("POST /api/outfits/{id}/favorite", scopedMiddleware(outfitHandler.FavoriteOutfit, domain.ScopeOutfitsWrite))
+	router.Handle("DELETE /api/outfits/{id}/favorite", scopedMiddleware(outfitHandler.UnfavoriteOutfit, domain.ScopeOutfitsWrite))
 
 	// Recommendation routes
-	router.Handle("GET /api/recommendations/daily", authMiddleware(http.HandlerFunc(recommendationHandler.GetDaily)))
-	router.Handle("GET /api/recommendations/explore", authMiddleware(http.HandlerFunc(recommendationHandler.GetExplore)))
-	router.Handle("POST /api/recommendations/feedback", authMiddleware(http.HandlerFunc(recommendationHandler.SubmitFeedback)))
+	router.Handle("GET /api/recommendations/daily", scopedMiddleware(limited(recommendationsRateLimit, recommendationHandler.GetDaily), domain.ScopeRecommendationsRead))
+	router.Handle("GET /api/recommendations/explore", scopedMiddleware(limited(recommendationsRateLimit, recommendationHandler.GetExplore), domain.ScopeRecommendationsRead))
+	router.Handle("POST /api/recommendations/feedback", scopedMiddleware(recommendationHandler.SubmitFeedback, domain.ScopeRecommendationsWrite))
+	router.Handle("GET /api/recommendations/gaps", scopedMiddleware(limited(recommendationsRateLimit, recommendationHandler.GetGaps), domain.ScopeRecommendationsRead))
+	router.Handle("POST /api/recommendations/gaps/wishlist", scopedMiddleware(recommendationHandler.AddGapsToWishlist, domain.ScopeRecommendationsRead, domain.ScopeWardrobeWrite))
+
+	// Search routes
+	router.Handle("GET /api/search", scopedMiddleware(searchHandler.Search, domain.ScopeWardrobeRead, domain.ScopeOutfitsRead))
+
+	// Sync routes
+	router.Handle("GET /api/sync", authMiddleware(http.HandlerFunc(syncHandler.Pull)))
+	router.Handle("POST /api/sync", authMiddleware(http.HandlerFunc(syncHandler.Push)))
+
+	// Export routes
+	router.Handle("GET /api/export", scopedMiddleware(exportHandler.Export, domain.ScopeWardrobeRead, domain.ScopeOutfitsRead))
+
+	// Account routes
+	router.Handle("GET /api/account/export", authMiddleware(http.HandlerFunc(accountHandler.Export)))
+	router.Handle("POST /api/account/deletion", authMiddleware(http.HandlerFunc(accountHandler.RequestDeletion)))
+	router.Handle("GET /api/account/deletion", authMiddleware(http.HandlerFunc(accountHandler.GetDeletion)))
+	router.Handle("DELETE /api/account/deletion", authMiddleware(http.HandlerFunc(accountHandler.CancelDeletion)))
+
+	// API key routes
+	router.Handle("GET /api/keys", authMiddleware(http.HandlerFunc(apiKeyHandler.ListKeys)))
+	router.Handle("POST /api/keys", authMiddleware(http.HandlerFunc(apiKeyHandler.CreateKey)))
+	router.Handle("DELETE /api/keys/{id}", authMiddleware(http.HandlerFunc(apiKeyHandler.RevokeKey)))
+
+	// Admin routes
+	router.Handle("GET /api/admin/users", adminMiddleware(domain.PermissionViewUsers, adminHandler.ListUsers))
+	router.Handle("GET /api/admin/users/{id}", adminMiddleware(domain.PermissionViewUsers, adminHandler.GetUser))
+	router.Handle("GET /api/admin/users/{id}/wardrobe", adminMiddleware(domain.PermissionViewWardrobes, adminHandler.GetUserItems))
+	router.Handle("POST /api/admin/users/{id}/disable", adminMiddleware(domain.PermissionManageUsers, adminHandler.DisableUser))
+	router.Handle("POST /api/admin/users/{id}/enable", adminMiddleware(domain.PermissionManageUsers, adminHandler.EnableUser))
+	router.Handle("GET /api/admin/categories", adminMiddleware(domain.PermissionManageTaxonomy, adminHandler.GetCategories))
+	router.Handle("POST /api/admin/categories", adminMiddleware(domain.PermissionManageTaxonomy, adminHandler.CreateCategory))
+	router.Handle("PUT /api/admin/categories/{id}", adminMiddleware(domain.PermissionManageTaxonomy, adminHandler.UpdateCategory))
+	router.Handle("DELETE /api/admin/categories/{id}", adminMiddleware(domain.PermissionManageTaxonomy, adminHandler.DeleteCategory))
+
+	// Webhook routes, authenticated by their signature rather than a user's token
+	if supabaseConfig.WebhookSecret != "" {
+		webhookSecret, err := middleware.ParseWebhookSecret(supabaseConfig.WebhookSecret)
+		if err != nil {
+			logger.Fatalf("Invalid SUPABASE_WEBHOOK_SECRET: %v", err)
+		}
+		router.Handle("POST /api/webhooks/supabase/auth", middleware.WebhookSignatureMiddleware(webhookSecret, idempotencyStore)(http.HandlerFunc(webhookHandler.SupabaseAuth)))
+	}
 
 	// Apply global middleware
 	handler := corsMiddleware(loggingMiddleware(router))
@@ -103,6 +262,21 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Backfill data derived from what users had already stored before the
+	// server takes requests
+	err = forEachUser(userRepo, func(user *domain.User) error {
+		if err := outfitService.RebuildWearStats(user.ID); err != nil {
+			return fmt.Errorf("failed to rebuild wear stats: %w", err)
+		}
+		if err := searchService.ReindexUser(user.ID); err != nil {
+			return fmt.Errorf("failed to rebuild search index: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Fatalf("Error backfilling stored data: %v", err)
+	}
+
 	// Start server in a goroutine
 	go func() {
 		logger.Printf("Server starting on port %s", port)
@@ -111,6 +285,21 @@ func main() {
 		}
 	}()
 
+	// Erase accounts whose deletion grace period has passed
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := accountService.DeleteDueAccounts()
+			if err != nil {
+				logger.Printf("Error deleting accounts: %v", err)
+			}
+			if deleted > 0 {
+				logger.Printf("Deleted %d accounts", deleted)
+			}
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -128,3 +317,23 @@ func main() {
 
 	logger.Println("Server exited properly")
 }
+
+// forEachUser calls fn for every user, a page at a time
+func forEachUser(userRepo domain.UserRepository, fn func(user *domain.User) error) error {
+	page := domain.PageRequest{Limit: domain.MaxPageLimit, SortBy: domain.SortByCreatedAt}
+	for {
+		users, pageInfo, err := userRepo.ListUsers(domain.UserQuery{}, page)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return fmt.Errorf("user %s: %w", user.ID, err)
+			}
+		}
+		if !pageInfo.HasMore {
+			return nil
+		}
+		page.Cursor = pageInfo.NextCursor
+	}
+}
@@ -0,0 +1,204 @@
+// Command lilo imports and exports wardrobes through the Lilo API.
+//
+// Usage:
+//
+//	lilo import [-format csv|json] [-map Source:field,...] [-dry-run] FILE
+//	lilo export [-format json|csv] [-o FILE]
+//
+// The API address and access token come from LILO_API_URL and LILO_TOKEN, or
+// the -api and -token flags. The token can be an API key created with
+// POST /api/keys: import needs the wardrobe:write scope, and export needs
+// wardrobe:read and outfits:read.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lilo:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lilo import [-format csv|json] [-map Source:field,...] [-dry-run] FILE")
+	fmt.Fprintln(os.Stderr, "       lilo export [-format json|csv] [-o FILE]")
+	os.Exit(2)
+}
+
+// client holds the API address and token shared by every command
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func clientFlags(flags *flag.FlagSet) *client {
+	c := &client{http: &http.Client{Timeout: 2 * time.Minute}}
+	apiURL := os.Getenv("LILO_API_URL")
+	if apiURL == "" {
+		apiURL = "http://localhost:8080"
+	}
+	flags.StringVar(&c.baseURL, "api", apiURL, "API base URL")
+	flags.StringVar(&c.token, "token", os.Getenv("LILO_TOKEN"), "access token or API key")
+	return c
+}
+
+// do sends a request and returns the response body, or the API's error message
+func (c *client) do(method, path string, query url.Values, contentType string, body io.Reader) ([]byte, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("an access token or API key is required: set LILO_TOKEN or pass -token")
+	}
+
+	endpoint := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	c := clientFlags(flags)
+	format := flags.String("format", "", "file format, csv or json (default: from the file extension)")
+	mapping := flags.String("map", "", "column mapping, e.g. Colour:color,Item Type:category")
+	dryRun := flags.Bool("dry-run", false, "validate and report without importing")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	contentType := "text/csv"
+	switch *format {
+	case domain.FormatCSV:
+	case domain.FormatJSON:
+		contentType = "application/json"
+	default:
+		return fmt.Errorf("can't tell the format of %s: pass -format csv or -format json", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	query := url.Values{"format": {*format}}
+	if *mapping != "" {
+		query.Set("map", *mapping)
+	}
+	if *dryRun {
+		query.Set("dryRun", "true")
+	}
+	data, err := c.do(http.MethodPost, "/api/wardrobe/import", query, contentType, file)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data domain.ImportReport `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("unexpected response: %w", err)
+	}
+	report := resp.Data
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case domain.ImportStatusInvalid:
+			fmt.Printf("row %d: invalid: %s\n", row.Row, row.Error)
+		case domain.ImportStatusDuplicate:
+			if row.DuplicateOfRow != 0 {
+				fmt.Printf("row %d: duplicate of row %d: %s\n", row.Row, row.DuplicateOfRow, row.Name)
+			} else {
+				fmt.Printf("row %d: already in wardrobe: %s\n", row.Row, row.Name)
+			}
+		}
+	}
+	if len(report.IgnoredColumns) > 0 {
+		fmt.Printf("ignored columns: %s\n", strings.Join(report.IgnoredColumns, ", "))
+	}
+	if report.DryRun {
+		fmt.Printf("dry run: %d of %d rows would be imported, %d duplicates, %d invalid\n",
+			report.Valid, report.Total, report.Duplicates, report.Invalid)
+	} else {
+		fmt.Printf("imported %d of %d rows, %d duplicates, %d invalid\n",
+			report.Created, report.Total, report.Duplicates, report.Invalid)
+	}
+
+	if report.Invalid > 0 {
+		return fmt.Errorf("%d rows could not be imported", report.Invalid)
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	c := clientFlags(flags)
+	format := flags.String("format", domain.FormatJSON, "export format, json or csv (a zip of CSV files)")
+	output := flags.String("o", "", "output file (default: standard output)")
+	flags.Parse(args)
+
+	data, err := c.do(http.MethodGet, "/api/export", url.Values{"format": {*format}}, "", nil)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0o600)
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+// DefaultDeletionGracePeriod is how long a requested account deletion waits,
+// so the user can change their mind, when ACCOUNT_DELETION_GRACE_PERIOD isn't set
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// AccountConfig holds account lifecycle configuration
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration
+}
+
+// GetAccountConfig returns the account lifecycle configuration
+func GetAccountConfig() *AccountConfig {
+	gracePeriod := DefaultDeletionGracePeriod
+	if value := getEnvVar("ACCOUNT_DELETION_GRACE_PERIOD"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("invalid ACCOUNT_DELETION_GRACE_PERIOD %q: must be a duration such as 720h", value)
+		}
+		gracePeriod = parsed
+	}
+	return &AccountConfig{DeletionGracePeriod: gracePeriod}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Default CORS policy, used when the CORS_* variables aren't set. Only local
+// development servers, such as Expo's web build, are allowed by default; the
+// native apps don't send an Origin and aren't affected.
+var (
+	DefaultCorsAllowedOrigins = []string{"http://localhost:*", "http://127.0.0.1:*"}
+	DefaultCorsAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	DefaultCorsAllowedHeaders = []string{"Content-Type", "Authorization", "Idempotency-Key", "If-Match", "If-None-Match"}
+	DefaultCorsExposedHeaders = []string{"ETag", "Content-Disposition", "Idempotent-Replayed",
+		"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}
+)
+
+// DefaultCorsMaxAge is how long browsers may cache a preflight response
+const DefaultCorsMaxAge = 10 * time.Minute
+
+// CorsConfig holds the cross-origin policy for browsers. Allowed origins are
+// exact, such as https://app.lilo.com, or patterns with one *, such as
+// https://*.lilo.com; a lone * allows any origin.
+type CorsConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// GetCorsConfig returns the CORS configuration. Lists are comma separated.
+func GetCorsConfig() *CorsConfig {
+	config := &CorsConfig{
+		AllowedOrigins:   getList("CORS_ALLOWED_ORIGINS", DefaultCorsAllowedOrigins),
+		AllowedMethods:   getList("CORS_ALLOWED_METHODS", DefaultCorsAllowedMethods),
+		AllowedHeaders:   getList("CORS_ALLOWED_HEADERS", DefaultCorsAllowedHeaders),
+		ExposedHeaders:   getList("CORS_EXPOSED_HEADERS", DefaultCorsExposedHeaders),
+		AllowCredentials: getEnvVar("CORS_ALLOW_CREDENTIALS") == "true",
+		MaxAge:           DefaultCorsMaxAge,
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			if config.AllowCredentials {
+				log.Fatal("invalid CORS_ALLOWED_ORIGINS: * can't be used with CORS_ALLOW_CREDENTIALS")
+			}
+			continue
+		}
+		if !strings.Contains(origin, "://") || strings.Count(origin, "*") > 1 || strings.HasSuffix(origin, "/") {
+			log.Fatalf("invalid CORS_ALLOWED_ORIGINS entry %q: must be an origin such as https://app.example.com, with at most one *", origin)
+		}
+	}
+
+	if value := getEnvVar("CORS_MAX_AGE"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("invalid CORS_MAX_AGE %q: must be a duration such as 10m", value)
+		}
+		config.MaxAge = parsed
+	}
+	return config
+}
+
+// getList returns a comma separated variable as a list, or the fallback when it's unset
+func getList(key string, fallback []string) []string {
+	value := getEnvVar(key)
+	if value == "" {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
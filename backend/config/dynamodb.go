@@ -18,6 +18,7 @@ const (
 	OutfitsTableName         = "LiloOutfits"
 	ReflectionsTableName     = "LiloReflections"
 	RecommendationsTableName = "LiloRecommendations"
+	UserCategoriesTableName  = "LiloUserCategories"
 )
 
 // CreateDynamoDBTables creates all required DynamoDB tables if they don't exist
@@ -314,6 +315,29 @@ func CreateDynamoDBTables(client *dynamodb.Client) error {
 				},
 			},
 		},
+		{
+			Name: UserCategoriesTableName,
+			KeySchema: []types.KeySchemaElement{
+				{
+					AttributeName: aws.String("userId"),
+					KeyType:       types.KeyTypeHash,
+				},
+				{
+					AttributeName: aws.String("id"),
+					KeyType:       types.KeyTypeRange,
+				},
+			},
+			AttributeDef: []types.AttributeDefinition{
+				{
+					AttributeName: aws.String("userId"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("id"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+			},
+		},
 	}
 
 	for _, table := range tables {
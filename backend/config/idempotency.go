@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long responses are kept for replay when
+// IDEMPOTENCY_TTL isn't set
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyConfig holds idempotency key configuration
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// GetIdempotencyConfig returns the idempotency key configuration
+func GetIdempotencyConfig() *IdempotencyConfig {
+	ttl := DefaultIdempotencyTTL
+	if value := getEnvVar("IDEMPOTENCY_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("invalid IDEMPOTENCY_TTL %q: must be a positive duration such as 24h", value)
+		}
+		ttl = parsed
+	}
+	return &IdempotencyConfig{TTL: ttl}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Requests requests per Window; zero disables limiting
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Default rate limits, used when the RATE_LIMIT_* variables aren't set
+var (
+	DefaultIPRateLimit              = RateLimit{Requests: 300, Window: time.Minute}
+	DefaultRateLimit                = RateLimit{Requests: 120, Window: time.Minute}
+	DefaultUploadRateLimit          = RateLimit{Requests: 10, Window: time.Minute}
+	DefaultRecommendationsRateLimit = RateLimit{Requests: 30, Window: time.Minute}
+)
+
+// RateLimitConfig holds the rate limit for each group of routes. IP limits
+// every authenticated route by address before the credentials are checked, so
+// failed attempts count too; it's looser than Default since many users can
+// share an address. Default covers every route per user; uploads and
+// recommendation generation, which are expensive, are additionally held to
+// their own limits.
+type RateLimitConfig struct {
+	IP              RateLimit
+	Default         RateLimit
+	Uploads         RateLimit
+	Recommendations RateLimit
+}
+
+// GetRateLimitConfig returns the rate limit configuration. Limits are written
+// as requests per duration, such as "120/1m", or "off".
+func GetRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		IP:              getRateLimit("RATE_LIMIT_IP", DefaultIPRateLimit),
+		Default:         getRateLimit("RATE_LIMIT_DEFAULT", DefaultRateLimit),
+		Uploads:         getRateLimit("RATE_LIMIT_UPLOADS", DefaultUploadRateLimit),
+		Recommendations: getRateLimit("RATE_LIMIT_RECOMMENDATIONS", DefaultRecommendationsRateLimit),
+	}
+}
+
+func getRateLimit(key string, fallback RateLimit) RateLimit {
+	value := getEnvVar(key)
+	switch value {
+	case "":
+		return fallback
+	case "off":
+		return RateLimit{}
+	}
+
+	requests, window, _ := strings.Cut(value, "/")
+	count, err := strconv.Atoi(requests)
+	if err != nil || count <= 0 {
+		log.Fatalf("invalid %s %q: must be requests per duration such as 120/1m, or off", key, value)
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration < time.Second {
+		log.Fatalf("invalid %s %q: must be requests per duration such as 120/1m, or off", key, value)
+	}
+	return RateLimit{Requests: count, Window: duration}
+}
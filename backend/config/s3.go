@@ -3,6 +3,8 @@ package config
 import (
 	"context"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -16,15 +18,47 @@ const (
 	OutfitImagesS3Bucket   = "lilo-outfit-images"
 )
 
-// CreateS3Buckets creates all required S3 buckets if they don't exist
-func CreateS3Buckets(client *s3.Client, region string) error {
-	buckets := []string{
-		UserImagesS3Bucket,
-		ClothingImagesS3Bucket,
-		OutfitImagesS3Bucket,
+// ImageS3Buckets lists every bucket that holds user images
+var ImageS3Buckets = []string{
+	UserImagesS3Bucket,
+	ClothingImagesS3Bucket,
+	OutfitImagesS3Bucket,
+}
+
+// DefaultSignedURLTTL is how long the signed URLs clients load images from
+// are valid when IMAGE_URL_TTL isn't set
+const DefaultSignedURLTTL = 15 * time.Minute
+
+// StorageConfig holds image storage configuration
+type StorageConfig struct {
+	S3Enabled    bool          // images are uploaded to the S3 buckets above
+	SignedURLTTL time.Duration // the buckets are private; images are served by signed URL
+}
+
+// GetStorageConfig returns the image storage configuration
+func GetStorageConfig() *StorageConfig {
+	ttl := DefaultSignedURLTTL
+	if value := getEnvVar("IMAGE_URL_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < time.Minute || parsed > 7*24*time.Hour {
+			log.Fatalf("invalid IMAGE_URL_TTL %q: must be a duration between 1m and 168h", value)
+		}
+		ttl = parsed
 	}
+	return &StorageConfig{
+		S3Enabled:    getEnvVar("S3_ENABLED") == "true",
+		SignedURLTTL: ttl,
+	}
+}
 
-	for _, bucket := range buckets {
+// CreateS3Buckets creates all required S3 buckets if they don't exist and
+// makes sure they're private: public access is blocked and any public read
+// policy left from earlier setups is removed. Browsers may only read images,
+// by signed URL, from the exact origins allowed to call the API; origin
+// patterns such as https://*.example.com aren't carried over.
+func CreateS3Buckets(client *s3.Client, region string, cors *CorsConfig) error {
+	origins := exactOrigins(cors.AllowedOrigins)
+	for _, bucket := range ImageS3Buckets {
 		// Check if bucket exists
 		_, err := client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
 			Bucket: aws.String(bucket),
@@ -32,69 +66,95 @@ func CreateS3Buckets(client *s3.Client, region string) error {
 
 		if err != nil {
 			// Bucket doesn't exist, create it
-			_, err = client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
-				Bucket: aws.String(bucket),
-				CreateBucketConfiguration: &types.CreateBucketConfiguration{
+			input := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+			// us-east-1 is the default location and can't be given as a constraint
+			if region != "us-east-1" {
+				input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
 					LocationConstraint: types.BucketLocationConstraint(region),
-				},
-			})
+				}
+			}
+			_, err = client.CreateBucket(context.TODO(), input)
 			if err != nil {
 				log.Printf("Error creating bucket %s: %v", bucket, err)
 				return err
 			}
 			log.Printf("Created bucket %s", bucket)
+		} else {
+			log.Printf("Bucket %s already exists", bucket)
+		}
 
-			// Set bucket policy for public read access to images
-			policy := `{
-				"Version": "2012-10-17",
-				"Statement": [
-					{
-						"Sid": "PublicReadGetObject",
-						"Effect": "Allow",
-						"Principal": "*",
-						"Action": "s3:GetObject",
-						"Resource": "arn:aws:s3:::` + bucket + `/*"
-					}
-				]
-			}`
-
-			_, err = client.PutBucketPolicy(context.TODO(), &s3.PutBucketPolicyInput{
-				Bucket: aws.String(bucket),
-				Policy: aws.String(policy),
-			})
-			if err != nil {
-				log.Printf("Error setting bucket policy for %s: %v", bucket, err)
-				return err
-			}
-			log.Printf("Set public read policy for bucket %s", bucket)
-
-			// Enable CORS for the bucket
-			maxAge := int32(3000)
-			corsRule := []types.CORSRule{
-				{
-					AllowedHeaders: []string{"*"},
-					AllowedMethods: []string{"GET", "PUT", "POST", "DELETE", "HEAD"},
-					AllowedOrigins: []string{"*"},
-					ExposeHeaders:  []string{"ETag"},
-					MaxAgeSeconds:  &maxAge,
-				},
-			}
+		// Block public access to images
+		_, err = client.PutPublicAccessBlock(context.TODO(), &s3.PutPublicAccessBlockInput{
+			Bucket: aws.String(bucket),
+			PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:       aws.Bool(true),
+				IgnorePublicAcls:      aws.Bool(true),
+				BlockPublicPolicy:     aws.Bool(true),
+				RestrictPublicBuckets: aws.Bool(true),
+			},
+		})
+		if err != nil {
+			log.Printf("Error blocking public access for bucket %s: %v", bucket, err)
+			return err
+		}
 
-			_, err = client.PutBucketCors(context.TODO(), &s3.PutBucketCorsInput{
+		// Remove the public read policy earlier versions attached
+		_, err = client.DeleteBucketPolicy(context.TODO(), &s3.DeleteBucketPolicyInput{
+			Bucket: aws.String(bucket),
+		})
+		if err != nil {
+			log.Printf("Error removing bucket policy for %s: %v", bucket, err)
+			return err
+		}
+		log.Printf("Made bucket %s private", bucket)
+
+		// Let browsers read images from the app's origins, and nothing else
+		if len(origins) == 0 {
+			_, err = client.DeleteBucketCors(context.TODO(), &s3.DeleteBucketCorsInput{
 				Bucket: aws.String(bucket),
-				CORSConfiguration: &types.CORSConfiguration{
-					CORSRules: corsRule,
-				},
 			})
 			if err != nil {
-				log.Printf("Error setting CORS for bucket %s: %v", bucket, err)
+				log.Printf("Error removing CORS for bucket %s: %v", bucket, err)
 				return err
 			}
-			log.Printf("Set CORS for bucket %s", bucket)
-		} else {
-			log.Printf("Bucket %s already exists", bucket)
+			log.Printf("Removed CORS for bucket %s: no exact origins are allowed", bucket)
+			continue
 		}
+
+		maxAge := int32(3000)
+		corsRule := []types.CORSRule{
+			{
+				AllowedMethods: []string{"GET", "HEAD"},
+				AllowedOrigins: origins,
+				ExposeHeaders:  []string{"ETag"},
+				MaxAgeSeconds:  &maxAge,
+			},
+		}
+
+		_, err = client.PutBucketCors(context.TODO(), &s3.PutBucketCorsInput{
+			Bucket: aws.String(bucket),
+			CORSConfiguration: &types.CORSConfiguration{
+				CORSRules: corsRule,
+			},
+		})
+		if err != nil {
+			log.Printf("Error setting CORS for bucket %s: %v", bucket, err)
+			return err
+		}
+		log.Printf("Set CORS for bucket %s", bucket)
 	}
 
 	return nil
 }
+
+// exactOrigins returns the allowed origins that name a single origin,
+// dropping * and patterns
+func exactOrigins(origins []string) []string {
+	var exact []string
+	for _, origin := range origins {
+		if !strings.Contains(origin, "*") {
+			exact = append(exact, origin)
+		}
+	}
+	return exact
+}
@@ -3,23 +3,65 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
-// SupabaseConfig holds Supabase configuration
+// DefaultJWTAudience is the audience Supabase puts in signed-in users' tokens
+const DefaultJWTAudience = "authenticated"
+
+// SupabaseConfig holds Supabase configuration. JWTSecret verifies tokens
+// signed with the legacy shared secret; JWKSURL serves the project's public
+// keys for tokens signed with asymmetric keys. Either may be empty, not both.
+// WebhookSecret signs auth events; the webhook is disabled when it's empty.
 type SupabaseConfig struct {
-	URL       string
-	AnonKey   string
-	JWTSecret string
+	URL           string
+	AnonKey       string
+	JWTSecret     string
+	JWKSURL       string
+	JWTIssuer     string
+	JWTAudience   string
+	WebhookSecret string
 }
 
-// GetSupabaseConfig returns the Supabase configuration
+// GetSupabaseConfig returns the Supabase configuration. The JWKS URL and token
+// issuer default to the project's auth endpoints; set SUPABASE_JWKS_URL to
+// "off" to accept only tokens signed with the shared secret.
 func GetSupabaseConfig() *SupabaseConfig {
+	url := strings.TrimSuffix(getEnvVar("SUPABASE_URL"), "/")
+
+	jwksURL := getEnvVar("SUPABASE_JWKS_URL")
+	if jwksURL == "" && url != "" {
+		jwksURL = url + "/auth/v1/.well-known/jwks.json"
+	}
+	if jwksURL == "off" {
+		jwksURL = ""
+	}
+
+	issuer := getEnvVar("SUPABASE_JWT_ISSUER")
+	if issuer == "" && url != "" {
+		issuer = url + "/auth/v1"
+	}
+
+	audience := getEnvVar("SUPABASE_JWT_AUDIENCE")
+	if audience == "" {
+		audience = DefaultJWTAudience
+	}
+
+	jwtSecret := getEnvVar("SUPABASE_JWT_SECRET")
+	if jwtSecret == "" && jwksURL == "" {
+		log.Fatal("SUPABASE_JWT_SECRET or SUPABASE_URL must be set to verify access tokens")
+	}
+
 	return &SupabaseConfig{
-		URL:       getEnvVar("SUPABASE_URL"),
-		AnonKey:   getEnvVar("SUPABASE_ANON_KEY"),
-		JWTSecret: getEnvVar("SUPABASE_JWT_SECRET"),
+		URL:           url,
+		AnonKey:       getEnvVar("SUPABASE_ANON_KEY"),
+		JWTSecret:     jwtSecret,
+		JWKSURL:       jwksURL,
+		JWTIssuer:     issuer,
+		JWTAudience:   audience,
+		WebhookSecret: getEnvVar("SUPABASE_WEBHOOK_SECRET"),
 	}
 }
 
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Account deletion states
+const (
+	DeletionStatusPending   = "pending"
+	DeletionStatusCancelled = "cancelled"
+	DeletionStatusCompleted = "completed"
+)
+
+// AccountDeletion tracks a user's request to erase their account. LastError
+// holds why the most recent attempt failed; failed deletions are retried.
+type AccountDeletion struct {
+	UserID       string     `json:"userId"`
+	Status       string     `json:"status"`
+	RequestedAt  time.Time  `json:"requestedAt"`
+	ScheduledFor time.Time  `json:"scheduledFor"`
+	CancelledAt  *time.Time `json:"cancelledAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+}
+
+// DeletionRepository defines the interface for account deletion requests.
+// Requests outlive the accounts they erase.
+type DeletionRepository interface {
+	Save(deletion *AccountDeletion) error
+	GetByUserID(userID string) (*AccountDeletion, error)
+	// ListDue returns pending deletions scheduled at or before now
+	ListDue(now time.Time) ([]*AccountDeletion, error)
+}
+
+// Audited account actions
+const (
+	AuditAccountExported          = "account.exported"
+	AuditAccountDeletionRequested = "account.deletion_requested"
+	AuditAccountDeletionCancelled = "account.deletion_cancelled"
+	AuditAccountDeletionFailed    = "account.deletion_failed"
+	AuditAccountDeleted           = "account.deleted"
+)
+
+// AuditEntry records an action on an account, and the admin who took it if it
+// wasn't the user. Entries hold IDs and counts but no personal data, so they
+// can be kept after the account is erased.
+type AuditEntry struct {
+	ID      string         `json:"id"`
+	UserID  string         `json:"userId"`
+	ActorID string         `json:"actorId,omitempty"`
+	Action  string         `json:"action"`
+	Details map[string]int `json:"details,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	At      time.Time      `json:"at"`
+}
+
+// AuditLog defines the interface for the append-only account audit trail
+type AuditLog interface {
+	Append(entry *AuditEntry) error
+	ListByUserID(userID string) ([]*AuditEntry, error)
+}
+
+// ImageStore defines the interface for images the app uploaded on a user's
+// behalf. Images hosted elsewhere, such as an identity provider's avatar,
+// aren't the store's and are reported with ErrImageNotStored. Stored images
+// are private: clients are given SignURL's short-lived URLs to load them.
+// Each user's images are kept under a key prefix of their user ID, and the
+// store only reads, deletes or signs an image for the user whose prefix it's
+// under, failing with ErrImageNotOwned for anyone else.
+type ImageStore interface {
+	Fetch(ownerID, url string) (data []byte, contentType string, err error)
+	Delete(ownerID, url string) error
+	// SignURL returns a URL anyone can load the image from until it expires.
+	// A signed URL given back to the store is treated as the image's URL.
+	SignURL(ownerID, url string) (string, error)
+}
+
+// AccountExport is everything held about a user, as written to the
+// account.json file of their export archive
+type AccountExport struct {
+	ExportedAt      time.Time           `json:"exportedAt"`
+	Profile         *User               `json:"profile"`
+	StyleProfile    *StyleProfile       `json:"styleProfile,omitempty"`
+	Categories      []*ClothingCategory `json:"categories"`
+	Items           []*ClothingItem     `json:"items"`
+	Outfits         []*Outfit           `json:"outfits"`
+	Reflections     []*Reflection       `json:"reflections"`
+	Recommendations []*Recommendation   `json:"recommendations"`
+	APIKeys         []*APIKey           `json:"apiKeys"`
+	Sessions        []*Session          `json:"sessions"`
+	Deletion        *AccountDeletion    `json:"deletion,omitempty"`
+	AuditLog        []*AuditEntry       `json:"auditLog"`
+	Images          []*ExportedImage    `json:"images"`
+}
+
+// ExportedImage lists an image referenced by the user's data. Stored images
+// are copied into the archive at Path; external ones are only listed.
+type ExportedImage struct {
+	URL      string `json:"url"`
+	Path     string `json:"path,omitempty"`
+	External bool   `json:"external,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AccountService defines the interface for exporting and erasing accounts
+type AccountService interface {
+	// ExportAccount writes a zip archive of all the user's data and images
+	ExportAccount(userID string, w io.Writer) error
+	RequestDeletion(userID string) (*AccountDeletion, error)
+	CancelDeletion(userID string) (*AccountDeletion, error)
+	GetDeletion(userID string) (*AccountDeletion, error)
+	// DeleteAccount erases an account now, skipping the grace period, as when
+	// the identity provider has already deleted the user
+	DeleteAccount(userID string) error
+	// DeleteDueAccounts erases every account whose grace period has passed and
+	// returns how many were erased
+	DeleteDueAccounts() (int, error)
+}
+
+// Error definitions
+var (
+	ErrDeletionNotFound = errors.New("no account deletion has been requested")
+	ErrDeletionPending  = errors.New("account deletion is already scheduled")
+	ErrImageNotStored   = errors.New("image is not held by this image store")
+	ErrImageNotOwned    = errors.New("image belongs to another user")
+)
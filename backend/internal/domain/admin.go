@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Role is a user's role in the app. Roles come from the identity provider's
+// token, never from anything the user can edit.
+type Role string
+
+// Roles, from least to most privileged
+const (
+	RoleUser    Role = "user"
+	RoleStylist Role = "stylist"
+	RoleAdmin   Role = "admin"
+)
+
+// Permission is an action beyond managing one's own wardrobe that a route can require
+type Permission string
+
+// Permissions
+const (
+	PermissionViewUsers      Permission = "users:read"
+	PermissionManageUsers    Permission = "users:manage"
+	PermissionViewWardrobes  Permission = "wardrobes:read"
+	PermissionManageTaxonomy Permission = "taxonomy:manage"
+)
+
+// rolePermissions lists what each role may do. Stylists curate the shared
+// category taxonomy; admins support users and can do everything.
+var rolePermissions = map[Role][]Permission{
+	RoleUser:    {},
+	RoleStylist: {PermissionManageTaxonomy},
+	RoleAdmin: {
+		PermissionViewUsers,
+		PermissionManageUsers,
+		PermissionViewWardrobes,
+		PermissionManageTaxonomy,
+	},
+}
+
+// ParseRole returns the role with the given name, ignoring case
+func ParseRole(name string) (Role, bool) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	_, ok := rolePermissions[role]
+	return role, ok
+}
+
+// Rank orders roles by privilege, so the highest of several can be chosen
+func (r Role) Rank() int {
+	switch r {
+	case RoleAdmin:
+		return 2
+	case RoleStylist:
+		return 1
+	}
+	return 0
+}
+
+// Can reports whether the role grants a permission
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleFromMetadata returns a user's app role from the identity provider's
+// app_metadata, which only the provider's service role can write. It may hold
+// a "role" or a "roles" list; the most privileged role wins. Extra names, such
+// as a token's role claim, are considered too. Anyone else is a plain user.
+func RoleFromMetadata(appMetadata map[string]interface{}, names ...string) Role {
+	role := RoleUser
+	consider := func(value interface{}) {
+		if name, ok := value.(string); ok {
+			if parsed, ok := ParseRole(name); ok && parsed.Rank() > role.Rank() {
+				role = parsed
+			}
+		}
+	}
+
+	consider(appMetadata["role"])
+	if roles, ok := appMetadata["roles"].([]interface{}); ok {
+		for _, value := range roles {
+			consider(value)
+		}
+	}
+	for _, name := range names {
+		consider(name)
+	}
+	return role
+}
+
+// UserQuery filters the admin user list. Search matches part of the email or
+// name, ignoring case.
+type UserQuery struct {
+	Search   string
+	Role     Role
+	Disabled *bool
+}
+
+// Audited admin actions
+const (
+	AuditUserDisabled = "admin.user_disabled"
+	AuditUserEnabled  = "admin.user_enabled"
+)
+
+// AdminService defines the interface for support and taxonomy management
+type AdminService interface {
+	ListUsers(query UserQuery, page PageRequest) ([]*User, *PageInfo, error)
+	GetUser(id string) (*User, error)
+	GetUserItems(userID string, query WardrobeQuery, page PageRequest) ([]*ClothingItem, *PageInfo, error)
+	// SetUserDisabled disables or re-enables an account on behalf of an admin
+	SetUserDisabled(actorID, userID string, disabled bool) (*User, error)
+	ListCategories() ([]*ClothingCategory, error)
+	CreateCategory(name string, subcategories []string) (*ClothingCategory, error)
+	// UpdateCategory renames a built-in category and replaces its
+	// subcategories. It fails with ErrCategoryInUse rather than drop a
+	// subcategory items are filed under.
+	UpdateCategory(id, name string, subcategories []string) (*ClothingCategory, error)
+	// DeleteCategory removes a built-in category. It fails with
+	// ErrCategoryInUse while any item is filed under it.
+	DeleteCategory(id string) error
+}
+
+// Error definitions
+var (
+	ErrAccountDisabled = errors.New("account is disabled")
+	ErrDisableSelf     = errors.New("admins cannot disable their own account")
+)
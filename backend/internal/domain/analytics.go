@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+)
+
+// WardrobeAnalytics summarizes how a user's owned wardrobe is being used
+type WardrobeAnalytics struct {
+	TotalItems           int                    `json:"totalItems"`
+	TotalValue           map[string]float64     `json:"totalValue"` // keyed by currency
+	UnwornDays           int                    `json:"unwornDays"`
+	Items                []*ItemUsage           `json:"items"`
+	UnwornItems          []*ItemUsage           `json:"unwornItems"`
+	DepartedItems        []*ItemUsage           `json:"departedItems"` // donated or sold
+	StatusCounts         map[ItemStatus]int     `json:"statusCounts"`
+	CategoryUtilization  []*CategoryUtilization `json:"categoryUtilization"`
+	CategoryDistribution map[string]int         `json:"categoryDistribution"`
+	ColorDistribution    map[string]int         `json:"colorDistribution"`
+}
+
+// ItemUsage describes how often a clothing item has been worn
+type ItemUsage struct {
+	ItemID        string     `json:"itemId"`
+	Name          string     `json:"name"`
+	Category      string     `json:"category"`
+	Color         string     `json:"color"`
+	Status        ItemStatus `json:"status"`
+	LeftAt        *time.Time `json:"leftAt,omitempty"` // when the item was donated or sold
+	WearCount     int        `json:"wearCount"`
+	LastWornAt    *time.Time `json:"lastWornAt,omitempty"`
+	PurchasePrice float64    `json:"purchasePrice,omitempty"`
+	Currency      string     `json:"currency,omitempty"`
+	CostPerWear   *float64   `json:"costPerWear,omitempty"` // nil when the item has no price
+}
+
+// CategoryUtilization reports the share of a category worn within the analysis window
+type CategoryUtilization struct {
+	Category           string  `json:"category"`
+	TotalItems         int     `json:"totalItems"`
+	WornItems          int     `json:"wornItems"`
+	UtilizationPercent float64 `json:"utilizationPercent"`
+}
+
+// AnalyticsService defines the interface for wardrobe analytics
+type AnalyticsService interface {
+	GetWardrobeAnalytics(userID string, unwornDays int) (*WardrobeAnalytics, error)
+}
@@ -0,0 +1,31 @@
+package domain
+
+// Allowed values for the optional structured attributes of a clothing item
+var (
+	Materials = []string{
+		"cotton", "linen", "wool", "cashmere", "silk", "denim", "leather", "suede",
+		"polyester", "nylon", "rayon", "spandex", "fleece", "down", "synthetic", "blend", "other",
+	}
+
+	Patterns = []string{
+		"solid", "striped", "plaid", "checked", "floral", "polka_dot", "animal", "geometric",
+		"graphic", "camouflage", "paisley", "other",
+	}
+
+	Fits = []string{"slim", "regular", "relaxed", "oversized", "tailored", "cropped"}
+
+	WaterproofLevels = []string{"none", "water_resistant", "waterproof"}
+
+	CareInstructions = []string{
+		"machine_wash", "machine_wash_cold", "hand_wash", "dry_clean", "tumble_dry",
+		"line_dry", "iron", "do_not_iron", "do_not_bleach", "do_not_tumble_dry",
+	}
+)
+
+// Bounds for the 1-5 attribute scales. Zero means the attribute isn't set.
+const (
+	MinFormality = 1 // loungewear
+	MaxFormality = 5 // black tie
+	MinWarmth    = 1 // very light
+	MaxWarmth    = 5 // very warm
+)
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+)
+
+// MaxBatchSize is the most operations a single batch request may contain
+const MaxBatchSize = 100
+
+// Outcomes of a single batch operation
+const (
+	BatchStatusCreated    = "created"
+	BatchStatusUpdated    = "updated"
+	BatchStatusDeleted    = "deleted"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back" // succeeded, then undone because an atomic batch failed
+)
+
+// BatchResult reports what happened to one operation in a batch
+type BatchResult struct {
+	Index  int           `json:"index"`
+	Status string        `json:"status"`
+	ID     string        `json:"id,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Item   *ClothingItem `json:"item,omitempty"`
+}
+
+// Error definitions
+var (
+	ErrBatchTooLarge           = errors.New("batch exceeds the maximum size")
+	ErrBatchAborted            = errors.New("atomic batch aborted: no changes were applied")
+	ErrTransactionsUnsupported = errors.New("atomic batches are not supported by this storage backend")
+)
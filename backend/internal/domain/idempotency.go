@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+)
+
+// IdempotencyRecord is the stored outcome of the first request made with an
+// Idempotency-Key. Until that request finishes the record is pending.
+type IdempotencyRecord struct {
+	UserID      string
+	Key         string
+	Fingerprint string // hash of the method, path and body the key was first used with
+	Pending     bool
+	StatusCode  int
+	Header      map[string][]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore defines the interface for storing responses by idempotency key
+type IdempotencyStore interface {
+	// Reserve claims a key for a new request. If the key is already in use and
+	// hasn't expired, the existing record is returned and nothing is reserved.
+	Reserve(userID, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete stores the response for a reserved key
+	Complete(userID, key string, statusCode int, header map[string][]string, body []byte) error
+	// Release frees a reserved key so the request can be retried
+	Release(userID, key string) error
+	// Purge drops all of a user's stored responses, for erasing their account
+	Purge(userID string) error
+}
@@ -6,31 +6,34 @@ import (
 
 // Outfit represents a collection of clothing items that form an outfit
 type Outfit struct {
-	ID           string    `json:"id"`
-	UserID       string    `json:"userId"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description,omitempty"`
-	Items        []string  `json:"items"` // IDs of clothing items
-	Occasion     []string  `json:"occasion"`
-	Season       []string  `json:"season"`
-	ImageURL     string    `json:"imageUrl,omitempty"`
-	IsRecommended bool      `json:"isRecommended"`
-	IsFavorite   bool      `json:"isFavorite"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID            string     `json:"id"`
+	UserID        string     `json:"userId"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description,omitempty"`
+	Items         []string   `json:"items"` // IDs of clothing items
+	Occasion      []string   `json:"occasion"`
+	Season        []string   `json:"season"`
+	ImageURL      string     `json:"imageUrl,omitempty"`
+	IsRecommended bool       `json:"isRecommended"`
+	IsFavorite    bool       `json:"isFavorite"`
+	WearCount     int        `json:"wearCount"`
+	LastWornAt    *time.Time `json:"lastWornAt,omitempty"`
+	Version       int64      `json:"version"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
 }
 
 // Reflection represents user feedback on an outfit they wore
 type Reflection struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"userId"`
-	OutfitID   string    `json:"outfitId"`
-	Date       time.Time `json:"date"`
-	Confidence int       `json:"confidence"` // 1-5 scale
-	Comfort    int       `json:"comfort"`    // 1-5 scale
+	ID          string    `json:"id"`
+	UserID      string    `json:"userId"`
+	OutfitID    string    `json:"outfitId"`
+	Date        time.Time `json:"date"`
+	Confidence  int       `json:"confidence"` // 1-5 scale
+	Comfort     int       `json:"comfort"`    // 1-5 scale
 	WouldRewear bool      `json:"wouldRewear"`
-	Notes      string    `json:"notes,omitempty"`
-	CreatedAt  time.Time `json:"createdAt"`
+	Notes       string    `json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
 }
 
 // Recommendation represents an outfit recommendation for a user
@@ -45,29 +48,50 @@ type Recommendation struct {
 	CreatedAt   time.Time `json:"createdAt"`
 }
 
+// WardrobeGap describes a missing item that would unlock new outfit combinations
+type WardrobeGap struct {
+	ID          string         `json:"id"`
+	Category    string         `json:"category"`
+	Subcategory string         `json:"subcategory"`
+	Color       string         `json:"color"`
+	IsNeutral   bool           `json:"isNeutral"`
+	NewOutfits  int            `json:"newOutfits"`
+	ByOccasion  map[string]int `json:"byOccasion"`
+	Score       int            `json:"score"` // new outfits weighted by the user's weekly schedule
+	Summary     string         `json:"summary"`
+	InWishlist  bool           `json:"inWishlist"`
+}
+
 // OutfitRepository defines the interface for outfit data operations
 type OutfitRepository interface {
 	CreateOutfit(outfit *Outfit) error
 	GetOutfitByID(id string) (*Outfit, error)
-	GetOutfitsByUserID(userID string, filters map[string]interface{}) ([]*Outfit, error)
+	GetOutfitsByUserID(userID string, query OutfitQuery) ([]*Outfit, error)
+	ListOutfitsByUserID(userID string, query OutfitQuery, page PageRequest) ([]*Outfit, *PageInfo, error)
 	UpdateOutfit(outfit *Outfit) error
 	DeleteOutfit(id string) error
 	SetFavorite(id string, favorite bool) error
+	// CreateReflection saves a reflection and, in the same write, counts it as
+	// a wear of its outfit
 	CreateReflection(reflection *Reflection) error
 	GetReflectionsByUserID(userID string) ([]*Reflection, error)
+	DeleteReflectionsByUserID(userID string) error
 }
 
 // OutfitService defines the interface for outfit business logic
 type OutfitService interface {
 	CreateOutfit(outfit *Outfit) error
 	GetOutfit(id string) (*Outfit, error)
-	GetUserOutfits(userID string, filters map[string]interface{}) ([]*Outfit, error)
+	GetUserOutfits(userID string, query OutfitQuery, page PageRequest) ([]*Outfit, *PageInfo, error)
 	UpdateOutfit(outfit *Outfit) error
 	DeleteOutfit(id string) error
 	FavoriteOutfit(id string) error
 	UnfavoriteOutfit(id string) error
 	SubmitReflection(reflection *Reflection) error
 	GetUserReflections(userID string) ([]*Reflection, error)
+	// RebuildWearStats recounts the wear stats of a user's outfits and items
+	// from their reflections
+	RebuildWearStats(userID string) error
 }
 
 // RecommendationRepository defines the interface for recommendation data operations
@@ -76,11 +100,14 @@ type RecommendationRepository interface {
 	GetRecommendationByID(id string) (*Recommendation, error)
 	GetRecommendationsByUserID(userID string) ([]*Recommendation, error)
 	UpdateRecommendation(recommendation *Recommendation) error
+	DeleteRecommendationsByUserID(userID string) error
 }
 
 // RecommendationService defines the interface for recommendation business logic
 type RecommendationService interface {
 	GetDailyRecommendations(userID string) ([]*Outfit, error)
-	GetExploreRecommendations(userID string, filters map[string]interface{}) ([]*Outfit, error)
+	GetExploreRecommendations(userID string, query OutfitQuery, page PageRequest) ([]*Outfit, *PageInfo, error)
 	SubmitFeedback(recommendationID string, feedback string) error
-}
\ No newline at end of file
+	GetWardrobeGaps(userID string, limit int) ([]*WardrobeGap, error)
+	AddGapsToWishlist(userID string, gapIDs []string) ([]*ClothingItem, error)
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+)
+
+// SortField is a field list endpoints can be ordered by
+type SortField string
+
+// Sortable fields
+const (
+	SortByCreatedAt SortField = "createdAt"
+	SortByUpdatedAt SortField = "updatedAt"
+	SortByName      SortField = "name"
+	SortByWearCount SortField = "wearCount"
+)
+
+// Page size limits for list endpoints
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// IsValid reports whether the field can be sorted on
+func (f SortField) IsValid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByName, SortByWearCount:
+		return true
+	}
+	return false
+}
+
+// PageRequest asks a repository for one page of a sorted list. The cursor is
+// opaque to callers: each backend encodes whatever it needs to resume, such as
+// the last key seen in memory or DynamoDB's LastEvaluatedKey.
+type PageRequest struct {
+	Cursor     string
+	Limit      int
+	SortBy     SortField
+	Descending bool
+}
+
+// PageInfo describes where a page sits in the full list
+type PageInfo struct {
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	Limit      int    `json:"limit"`
+}
+
+// Error definitions
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StringFilter matches a field against sets of values, ignoring case. A value
+// matches when it is one of Include (or Include is empty) and none of Exclude.
+type StringFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// IsEmpty reports whether the filter matches everything
+func (f StringFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Matches checks a single-valued field against the filter
+func (f StringFilter) Matches(value string) bool {
+	if len(f.Include) > 0 && !containsFold(f.Include, value) {
+		return false
+	}
+	return !containsFold(f.Exclude, value)
+}
+
+// MatchesAny checks a multi-valued field against the filter: at least one value
+// must be included and none may be excluded
+func (f StringFilter) MatchesAny(values []string) bool {
+	if len(f.Include) > 0 {
+		found := false
+		for _, v := range values {
+			if containsFold(f.Include, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, v := range values {
+		if containsFold(f.Exclude, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// IntRange bounds a 1-5 attribute scale. Zero leaves a bound open. Items that
+// don't have the attribute set never match a bounded range.
+type IntRange struct {
+	Min int
+	Max int
+}
+
+// IsEmpty reports whether the range is unbounded
+func (r IntRange) IsEmpty() bool {
+	return r.Min == 0 && r.Max == 0
+}
+
+// Matches checks a value against the range
+func (r IntRange) Matches(value int) bool {
+	if r.IsEmpty() {
+		return true
+	}
+	if value == 0 {
+		return false
+	}
+	return (r.Min == 0 || value >= r.Min) && (r.Max == 0 || value <= r.Max)
+}
+
+func (r IntRange) validate(field string, lo, hi int) error {
+	for _, bound := range []int{r.Min, r.Max} {
+		if bound != 0 && (bound < lo || bound > hi) {
+			return fmt.Errorf("%s must be between %d and %d", field, lo, hi)
+		}
+	}
+	if r.Min != 0 && r.Max != 0 && r.Min > r.Max {
+		return fmt.Errorf("minimum %s cannot be greater than maximum", field)
+	}
+	return nil
+}
+
+// TimeRange bounds a timestamp, inclusive at both ends. A nil bound is open.
+// Records without the timestamp never match a bounded range.
+type TimeRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// IsEmpty reports whether the range is unbounded
+func (r TimeRange) IsEmpty() bool {
+	return r.From == nil && r.To == nil
+}
+
+// Matches checks a timestamp against the range
+func (r TimeRange) Matches(t *time.Time) bool {
+	if r.IsEmpty() {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	return (r.From == nil || !t.Before(*r.From)) && (r.To == nil || !t.After(*r.To))
+}
+
+func (r TimeRange) validate(field string) error {
+	if r.From != nil && r.To != nil && r.From.After(*r.To) {
+		return fmt.Errorf("%s range starts after it ends", field)
+	}
+	return nil
+}
+
+// WardrobeQuery selects clothing items. Zero-valued fields don't filter.
+type WardrobeQuery struct {
+	Category   StringFilter
+	Color      StringFilter
+	Season     StringFilter
+	Status     StringFilter
+	Material   StringFilter
+	Pattern    StringFilter
+	Fit        StringFilter
+	Waterproof StringFilter
+	Care       StringFilter
+	IsOwned    *bool
+	Formality  IntRange
+	Warmth     IntRange
+	Created    TimeRange
+	Purchased  TimeRange
+	LastWorn   TimeRange
+}
+
+// Validate checks that the query's values and ranges make sense
+func (q WardrobeQuery) Validate() error {
+	for _, status := range append(q.Status.Include, q.Status.Exclude...) {
+		if !ItemStatus(strings.ToLower(status)).IsValid() {
+			return fmt.Errorf("invalid status: %s", status)
+		}
+	}
+	if err := q.Formality.validate("formality", MinFormality, MaxFormality); err != nil {
+		return err
+	}
+	if err := q.Warmth.validate("warmth", MinWarmth, MaxWarmth); err != nil {
+		return err
+	}
+	if err := q.Created.validate("created"); err != nil {
+		return err
+	}
+	if err := q.Purchased.validate("purchased"); err != nil {
+		return err
+	}
+	return q.LastWorn.validate("last worn")
+}
+
+// OutfitQuery selects outfits. Zero-valued fields don't filter.
+type OutfitQuery struct {
+	Occasion      StringFilter
+	Season        StringFilter
+	Item          StringFilter // IDs of items the outfit contains
+	IsFavorite    *bool
+	IsRecommended *bool
+	Created       TimeRange
+	LastWorn      TimeRange
+}
+
+// Validate checks that the query's ranges make sense
+func (q OutfitQuery) Validate() error {
+	if err := q.Created.validate("created"); err != nil {
+		return err
+	}
+	return q.LastWorn.validate("last worn")
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
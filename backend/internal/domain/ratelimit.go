@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// RateLimit allows Requests requests per Window. Requests are drawn from a
+// token bucket holding up to Requests tokens that refills steadily over the
+// window, so short bursts are allowed as long as the average rate holds.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimitResult is the state of a bucket after a request tried to take a token
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is how long until a token is available, when none was
+	RetryAfter time.Duration
+}
+
+// RateLimitStore holds token buckets by key. Taking a token must be atomic,
+// so a store shared between instances has to check and update in one step.
+type RateLimitStore interface {
+	// Take removes a token from the bucket for key, if it has one
+	Take(key string, limit RateLimit) (*RateLimitResult, error)
+}
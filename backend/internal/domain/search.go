@@ -0,0 +1,51 @@
+package domain
+
+// Search document types
+const (
+	SearchTypeItem   = "item"
+	SearchTypeOutfit = "outfit"
+)
+
+// SearchQuery describes a keyword search over a user's wardrobe and outfits
+type SearchQuery struct {
+	UserID   string
+	Text     string
+	Types    []string // SearchTypeItem and/or SearchTypeOutfit; empty means both
+	Category string
+	Color    string
+	Season   string
+	Occasion string
+	Limit    int
+}
+
+// SearchHit is a single matching document
+type SearchHit struct {
+	Type   string        `json:"type"`
+	ID     string        `json:"id"`
+	Score  float64       `json:"score"`
+	Item   *ClothingItem `json:"item,omitempty"`
+	Outfit *Outfit       `json:"outfit,omitempty"`
+}
+
+// SearchResults holds the hits for a query along with facet counts over all matches
+type SearchResults struct {
+	Total  int                       `json:"total"`
+	Hits   []*SearchHit              `json:"hits"`
+	Facets map[string]map[string]int `json:"facets"` // facet name -> value -> count
+}
+
+// SearchIndex defines the interface for the keyword search index
+type SearchIndex interface {
+	IndexItem(item *ClothingItem)
+	IndexOutfit(outfit *Outfit)
+	Remove(docType, id string)
+	Search(query *SearchQuery) (*SearchResults, error)
+}
+
+// SearchService defines the interface for search business logic
+type SearchService interface {
+	Search(query *SearchQuery) (*SearchResults, error)
+	// ReindexUser indexes all of a user's items and outfits, for records
+	// stored before the index was kept up to date by writes
+	ReindexUser(userID string) error
+}
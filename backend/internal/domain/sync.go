@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Record types carried by sync
+const (
+	SyncTypeItem         = "item"
+	SyncTypeOutfit       = "outfit"
+	SyncTypeReflection   = "reflection"
+	SyncTypeProfile      = "profile"
+	SyncTypeStyleProfile = "styleProfile"
+)
+
+// Sync mutation operations
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+)
+
+// Outcomes of applying a single sync mutation
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+)
+
+// Size limits for pulling and pushing changes
+const (
+	DefaultSyncLimit = 500
+	MaxSyncLimit     = 1000
+	MaxSyncMutations = 500
+)
+
+// Change records the latest write to a record. Deleted changes are tombstones
+// that let offline clients learn about deletions they missed.
+type Change struct {
+	Seq       int64
+	UserID    string
+	Type      string
+	ID        string
+	Deleted   bool
+	ChangedAt time.Time
+}
+
+// ChangeLog keeps the latest change to each of a user's records in the order
+// they happened
+type ChangeLog interface {
+	Record(userID, recordType, id string, deleted bool)
+	// Since returns up to limit of the user's changes after seq, oldest first,
+	// and whether more remain
+	Since(userID string, seq int64, limit int) ([]*Change, bool, error)
+	// Purge forgets all of a user's changes, for erasing their account
+	Purge(userID string)
+}
+
+// SyncChange is a changed record, or a tombstone, as sent to clients
+type SyncChange struct {
+	Type         string        `json:"type"`
+	ID           string        `json:"id"`
+	Deleted      bool          `json:"deleted"`
+	ChangedAt    time.Time     `json:"changedAt"`
+	Item         *ClothingItem `json:"item,omitempty"`
+	Outfit       *Outfit       `json:"outfit,omitempty"`
+	Reflection   *Reflection   `json:"reflection,omitempty"`
+	Profile      *User         `json:"profile,omitempty"`
+	StyleProfile *StyleProfile `json:"styleProfile,omitempty"`
+}
+
+// SyncPull is the set of changes since a client's last sync. Token is passed
+// back as since on the next pull; HasMore means the client should pull again.
+type SyncPull struct {
+	Token   string        `json:"token"`
+	HasMore bool          `json:"hasMore"`
+	Changes []*SyncChange `json:"changes"`
+}
+
+// SyncMutation is a change a client made while offline. BaseVersion is the
+// version the client edited; zero for records created offline or to overwrite
+// unconditionally.
+type SyncMutation struct {
+	Type         string        `json:"type"`
+	Op           string        `json:"op"`
+	ID           string        `json:"id"`
+	BaseVersion  int64         `json:"baseVersion,omitempty"`
+	Item         *ClothingItem `json:"item,omitempty"`
+	Outfit       *Outfit       `json:"outfit,omitempty"`
+	Reflection   *Reflection   `json:"reflection,omitempty"`
+	Profile      *User         `json:"profile,omitempty"`
+	StyleProfile *StyleProfile `json:"styleProfile,omitempty"`
+}
+
+// SyncResult reports what happened to one mutation. On a conflict the
+// server's current copy is included so the client can resolve it.
+type SyncResult struct {
+	Type         string        `json:"type"`
+	ID           string        `json:"id"`
+	Status       string        `json:"status"`
+	Error        string        `json:"error,omitempty"`
+	Item         *ClothingItem `json:"item,omitempty"`
+	Outfit       *Outfit       `json:"outfit,omitempty"`
+	Reflection   *Reflection   `json:"reflection,omitempty"`
+	Profile      *User         `json:"profile,omitempty"`
+	StyleProfile *StyleProfile `json:"styleProfile,omitempty"`
+}
+
+// SyncService defines the interface for offline sync business logic
+type SyncService interface {
+	Pull(userID, token string, limit int) (*SyncPull, error)
+	Push(userID string, mutations []*SyncMutation) ([]*SyncResult, error)
+}
+
+// Error definitions
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+)
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// File formats for wardrobe import and export
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// MaxImportRows is the most items a single import may contain
+const MaxImportRows = 5000
+
+// Outcomes of importing a single row
+const (
+	ImportStatusCreated   = "created"
+	ImportStatusValid     = "valid" // would be created; reported by dry runs
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusInvalid   = "invalid"
+)
+
+// ImportOptions controls how an import file is read. Mapping renames source
+// columns (or JSON keys) to item fields, e.g. "Colour" -> "color"; columns
+// that match a field name already need no mapping.
+type ImportOptions struct {
+	Format  string
+	Mapping map[string]string
+	DryRun  bool
+}
+
+// ImportRow reports what happened to one row of an import. Row is the line the
+// row starts on for CSV, where the header is line 1, and the 1-based position
+// in the list for JSON.
+type ImportRow struct {
+	Row            int    `json:"row"`
+	Status         string `json:"status"`
+	Name           string `json:"name,omitempty"`
+	ID             string `json:"id,omitempty"`
+	DuplicateOf    string `json:"duplicateOf,omitempty"`    // ID of the existing item it matches
+	DuplicateOfRow int    `json:"duplicateOfRow,omitempty"` // earlier row of the same import it matches
+	Error          string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import. Duplicates and invalid rows are skipped
+// while the rest are imported, unless it was a dry run.
+type ImportReport struct {
+	DryRun         bool         `json:"dryRun"`
+	Total          int          `json:"total"`
+	Created        int          `json:"created"`
+	Valid          int          `json:"valid"`
+	Duplicates     int          `json:"duplicates"`
+	Invalid        int          `json:"invalid"`
+	IgnoredColumns []string     `json:"ignoredColumns,omitempty"`
+	Rows           []*ImportRow `json:"rows"`
+}
+
+// WardrobeExport is a full copy of a user's wardrobe, outfits and reflections
+type WardrobeExport struct {
+	ExportedAt  time.Time       `json:"exportedAt"`
+	Items       []*ClothingItem `json:"items"`
+	Outfits     []*Outfit       `json:"outfits"`
+	Reflections []*Reflection   `json:"reflections"`
+}
+
+// ExportService defines the interface for exporting a user's data
+type ExportService interface {
+	Export(userID string) (*WardrobeExport, error)
+	// WriteExport writes the export as JSON, or for CSV as a zip archive
+	// with one file per record type
+	WriteExport(userID, format string, w io.Writer) error
+}
+
+// Error definitions
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format: must be csv or json")
+	ErrInvalidImport     = errors.New("invalid import file")
+	ErrImportTooLarge    = errors.New("import exceeds the maximum number of rows")
+)
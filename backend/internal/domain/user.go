@@ -7,25 +7,39 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID         string    `json:"id"`
-	SupabaseID string    `json:"supabaseId"`
-	Email      string    `json:"email"`
-	Password   string    `json:"-"` // Password is never returned in JSON
-	Name       string    `json:"name,omitempty"`
-	Picture    string    `json:"picture,omitempty"`
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID         string `json:"id"`
+	SupabaseID string `json:"supabaseId"`
+	Email      string `json:"email"`
+	Name       string `json:"name,omitempty"`
+	Picture    string `json:"picture,omitempty"`
+	// ProviderName and ProviderPicture are the values last copied from the
+	// identity provider, so a change there can be told from a local edit
+	ProviderName    string     `json:"-"`
+	ProviderPicture string     `json:"-"`
+	Role            Role       `json:"role"`
+	DisabledAt      *time.Time `json:"disabledAt,omitempty"`
+	// SessionsRevokedAt is when the user last signed out everywhere; tokens
+	// issued before it are rejected
+	SessionsRevokedAt *time.Time `json:"-"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// IsDisabled reports whether an admin has disabled the account
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
 
 // StyleProfile represents a user's style preferences
 type StyleProfile struct {
-	ID                 string         `json:"id"`
-	UserID             string         `json:"userId"`
-	PreferredStyles    []string       `json:"preferredStyles"`
-	WeeklySchedule     WeeklySchedule `json:"weeklySchedule"`
+	ID                  string              `json:"id"`
+	UserID              string              `json:"userId"`
+	PreferredStyles     []string            `json:"preferredStyles"`
+	WeeklySchedule      WeeklySchedule      `json:"weeklySchedule"`
 	SeasonalPreferences map[string][]string `json:"seasonalPreferences"`
-	ColorPreferences   []string       `json:"colorPreferences"`
-	UpdatedAt          time.Time      `json:"updatedAt"`
+	ColorPreferences    []string            `json:"colorPreferences"`
+	Version             int64               `json:"version"`
+	UpdatedAt           time.Time           `json:"updatedAt"`
 }
 
 // WeeklySchedule represents a user's weekly clothing needs
@@ -39,14 +53,26 @@ type WeeklySchedule struct {
 	Sunday    string `json:"sunday"`
 }
 
-// UserRepository defines the interface for user data operations
+// UserRepository defines the interface for user data operations. Supabase IDs
+// and emails are unique: Create and Update fail with ErrUserExists or
+// ErrEmailTaken rather than store a second user with either.
 type UserRepository interface {
 	Create(user *User) error
 	GetByID(id string) (*User, error)
 	GetByEmail(email string) (*User, error)
 	GetBySupabaseID(supabaseID string) (*User, error)
+	// Update writes a user's profile. DisabledAt and SessionsRevokedAt are
+	// left as stored; they're only changed through their own setters, so a
+	// profile write made from a stale copy can't undo them.
 	Update(user *User) error
+	SetDisabledAt(id string, disabledAt *time.Time) (*User, error)
+	SetSessionsRevokedAt(id string, revokedAt time.Time) error
 	Delete(id string) error
+	// BlockSupabaseID makes Create fail with ErrUserDeleted for a Supabase ID
+	// until the given time, so events for an identity the provider deleted
+	// that arrive out of order can't give it a new account
+	BlockSupabaseID(supabaseID string, until time.Time) error
+	ListUsers(query UserQuery, page PageRequest) ([]*User, *PageInfo, error)
 	GetStyleProfile(userID string) (*StyleProfile, error)
 	SaveStyleProfile(profile *StyleProfile) error
 }
@@ -54,23 +80,30 @@ type UserRepository interface {
 // Error definitions
 var (
 	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+	ErrEmailTaken   = errors.New("email is already used by another account")
+	ErrUserDeleted  = errors.New("user has been deleted")
 )
 
 // Context key for user in request context
 type contextKey string
+
 const ContextKeyUser contextKey = "user"
 
 // UserService defines the interface for user business logic
 type UserService interface {
-	CreateUser(email, password, name string) (*User, error)
 	CreateUserFromSupabase(user *User) error
+	// ProvisionSupabaseUser returns the user for a Supabase identity, creating
+	// them on first sign-in and refreshing profile fields the provider changed
+	ProvisionSupabaseUser(identity *User) (*User, error)
 	GetUser(id string) (*User, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUserBySupabaseID(supabaseID string) (*User, error)
+	// BlockSupabaseUser keeps a Supabase identity the provider deleted from
+	// being provisioned again until the given time
+	BlockSupabaseUser(supabaseID string, until time.Time) error
 	UpdateUser(user *User) error
 	DeleteUser(id string) error
-	Authenticate(email, password string) (*User, string, error)
-	VerifyToken(token string) (*User, error)
 	GetStyleProfile(userID string) (*StyleProfile, error)
 	SaveStyleProfile(profile *StyleProfile) error
-}
\ No newline at end of file
+}
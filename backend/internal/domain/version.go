@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"errors"
+)
+
+// ErrVersionConflict is returned when an update's version doesn't match the
+// stored record. Items, outfits and style profiles carry a Version that starts
+// at 1 and is bumped by the repository on every write; an update must carry
+// the version it was based on, so a mismatch means another client changed the
+// record first.
+var ErrVersionConflict = errors.New("version conflict: the resource was modified by another request")
@@ -1,49 +1,175 @@
 package domain
 
 import (
+	"errors"
+	"io"
 	"time"
 )
 
+// ItemStatus is the lifecycle state of an owned clothing item
+type ItemStatus string
+
+// Item lifecycle states
+const (
+	ItemStatusActive    ItemStatus = "active"
+	ItemStatusInLaundry ItemStatus = "in_laundry"
+	ItemStatusAtTailor  ItemStatus = "at_tailor"
+	ItemStatusStored    ItemStatus = "stored"
+	ItemStatusDonated   ItemStatus = "donated"
+	ItemStatusSold      ItemStatus = "sold"
+)
+
+// itemStatusTransitions lists the states each state may move to.
+// Donated and sold are terminal: the item has left the wardrobe.
+var itemStatusTransitions = map[ItemStatus][]ItemStatus{
+	ItemStatusActive:    {ItemStatusInLaundry, ItemStatusAtTailor, ItemStatusStored, ItemStatusDonated, ItemStatusSold},
+	ItemStatusInLaundry: {ItemStatusActive, ItemStatusStored},
+	ItemStatusAtTailor:  {ItemStatusActive},
+	ItemStatusStored:    {ItemStatusActive, ItemStatusDonated, ItemStatusSold},
+	ItemStatusDonated:   {},
+	ItemStatusSold:      {},
+}
+
+// IsValid reports whether the status is a known lifecycle state
+func (s ItemStatus) IsValid() bool {
+	_, ok := itemStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an item may move from s to next
+func (s ItemStatus) CanTransitionTo(next ItemStatus) bool {
+	for _, allowed := range itemStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusTransition records a single lifecycle change of an item
+type StatusTransition struct {
+	From ItemStatus `json:"from,omitempty"`
+	To   ItemStatus `json:"to"`
+	At   time.Time  `json:"at"`
+	Note string     `json:"note,omitempty"`
+}
+
 // ClothingItem represents a clothing item in a user's wardrobe
 type ClothingItem struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"userId"`
-	Name       string    `json:"name"`
-	Category   string    `json:"category"`
-	Subcategory string   `json:"subcategory"`
-	Color      string    `json:"color"`
-	Season     []string  `json:"season"`
-	Brand      string    `json:"brand,omitempty"`
-	Size       string    `json:"size,omitempty"`
-	ImageURLs  []string  `json:"imageUrls"`
-	IsOwned    bool      `json:"isOwned"` // true for owned, false for wishlist
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
-}
-
-// ClothingCategory represents a category of clothing items
+	ID               string             `json:"id"`
+	UserID           string             `json:"userId"`
+	Name             string             `json:"name"`
+	Category         string             `json:"category"`
+	Subcategory      string             `json:"subcategory"`
+	Color            string             `json:"color"`
+	Season           []string           `json:"season"`
+	Brand            string             `json:"brand,omitempty"`
+	Size             string             `json:"size,omitempty"`
+	ImageURLs        []string           `json:"imageUrls"`
+	IsOwned          bool               `json:"isOwned"` // true for owned, false for wishlist
+	Material         string             `json:"material,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	Fit              string             `json:"fit,omitempty"`
+	Formality        int                `json:"formality,omitempty"` // 1 (loungewear) to 5 (black tie)
+	Warmth           int                `json:"warmth,omitempty"`    // 1 (very light) to 5 (very warm)
+	Waterproof       string             `json:"waterproof,omitempty"`
+	CareInstructions []string           `json:"careInstructions,omitempty"`
+	Notes            string             `json:"notes,omitempty"`
+	WearCount        int                `json:"wearCount"`
+	LastWornAt       *time.Time         `json:"lastWornAt,omitempty"`
+	PurchasePrice    float64            `json:"purchasePrice,omitempty"`
+	Currency         string             `json:"currency,omitempty"` // ISO 4217 code, e.g. USD
+	PurchaseDate     *time.Time         `json:"purchaseDate,omitempty"`
+	Status           ItemStatus         `json:"status"`
+	StatusHistory    []StatusTransition `json:"statusHistory,omitempty"`
+	Version          int64              `json:"version"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt"`
+}
+
+// CurrentStatus returns the item's lifecycle state, treating items saved
+// before lifecycle tracking existed as active
+func (i *ClothingItem) CurrentStatus() ItemStatus {
+	if i.Status == "" {
+		return ItemStatusActive
+	}
+	return i.Status
+}
+
+// InWardrobe reports whether the item is owned and hasn't left the wardrobe
+func (i *ClothingItem) InWardrobe() bool {
+	status := i.CurrentStatus()
+	return i.IsOwned && status != ItemStatusDonated && status != ItemStatusSold
+}
+
+// IsWearable reports whether the item is owned and available to wear today
+func (i *ClothingItem) IsWearable() bool {
+	return i.IsOwned && i.CurrentStatus() == ItemStatusActive
+}
+
+// ClothingCategory represents a category of clothing items. Built-in categories
+// are shared by every user; personal categories and subcategories belong to one user.
 type ClothingCategory struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Subcategories []string `json:"subcategories"`
+	ID                  string   `json:"id"`
+	UserID              string   `json:"userId,omitempty"` // set for personal categories and extensions
+	Name                string   `json:"name"`
+	Subcategories       []string `json:"subcategories"`
+	IsCustom            bool     `json:"isCustom"`                      // true for a user's own category
+	CustomSubcategories []string `json:"customSubcategories,omitempty"` // user additions to a built-in category
 }
 
 // WardrobeRepository defines the interface for wardrobe data operations
 type WardrobeRepository interface {
 	CreateItem(item *ClothingItem) error
 	GetItemByID(id string) (*ClothingItem, error)
-	GetItemsByUserID(userID string, filters map[string]interface{}) ([]*ClothingItem, error)
+	GetItemsByUserID(userID string, query WardrobeQuery) ([]*ClothingItem, error)
+	ListItemsByUserID(userID string, query WardrobeQuery, page PageRequest) ([]*ClothingItem, *PageInfo, error)
 	UpdateItem(item *ClothingItem) error
 	DeleteItem(id string) error
+	// RecordItemWear counts one more wear of an item, worn at wornAt, without
+	// the version check an update makes
+	RecordItemWear(id string, wornAt time.Time) error
 	GetCategories() ([]*ClothingCategory, error)
+	// SaveCategory creates or replaces a built-in category
+	SaveCategory(category *ClothingCategory) error
+	DeleteCategory(id string) error
+	GetUserCategories(userID string) ([]*ClothingCategory, error)
+	SaveUserCategory(category *ClothingCategory) error
+	DeleteUserCategory(userID, id string) error
+}
+
+// WardrobeTransactor is implemented by wardrobe repositories that can apply a
+// group of item and personal category writes all-or-nothing
+type WardrobeTransactor interface {
+	// WithinTransaction runs fn against a repository whose item and personal
+	// category writes are kept if fn returns nil and rolled back if it returns
+	// an error
+	WithinTransaction(fn func(tx WardrobeRepository) error) error
 }
 
 // WardrobeService defines the interface for wardrobe business logic
 type WardrobeService interface {
 	AddItem(item *ClothingItem) error
 	GetItem(id string) (*ClothingItem, error)
-	GetUserItems(userID string, filters map[string]interface{}) ([]*ClothingItem, error)
+	GetUserItems(userID string, query WardrobeQuery, page PageRequest) ([]*ClothingItem, *PageInfo, error)
 	UpdateItem(item *ClothingItem) error
 	DeleteItem(id string) error
-	GetCategories() ([]*ClothingCategory, error)
-}
\ No newline at end of file
+	GetCategories(userID string) ([]*ClothingCategory, error)
+	AddCategory(userID, name string, subcategories []string) (*ClothingCategory, error)
+	AddSubcategory(userID, categoryID, name string) (*ClothingCategory, error)
+	RenameCategory(userID, categoryID, name string) (*ClothingCategory, error)
+	ChangeItemStatus(id string, status ItemStatus, note string) (*ClothingItem, error)
+	BatchAddItems(userID string, items []*ClothingItem, atomic bool) ([]*BatchResult, error)
+	BatchUpdateItems(userID string, items []*ClothingItem, atomic bool) ([]*BatchResult, error)
+	BatchDeleteItems(userID string, ids []string, atomic bool) ([]*BatchResult, error)
+	ImportItems(userID string, r io.Reader, opts ImportOptions) (*ImportReport, error)
+}
+
+// Error definitions
+var (
+	ErrItemNotFound            = errors.New("item not found")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrCategoryNotFound        = errors.New("category not found")
+	ErrCategoryExists          = errors.New("category already exists")
+	ErrCategoryInUse           = errors.New("category is in use")
+)
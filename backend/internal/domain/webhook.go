@@ -0,0 +1,22 @@
+package domain
+
+// Auth event types, named after the change a database webhook on Supabase's
+// auth.users table reports
+const (
+	AuthEventUserCreated = "INSERT"
+	AuthEventUserUpdated = "UPDATE"
+	AuthEventUserDeleted = "DELETE"
+)
+
+// AuthEvent is a change to a user in the identity provider. Identity holds the
+// provider's view of the user: their Supabase ID, email, name, avatar and role.
+type AuthEvent struct {
+	Type     string
+	Identity *User
+}
+
+// AuthWebhookService defines the interface for applying identity provider
+// events to the app's users
+type AuthWebhookService interface {
+	HandleAuthEvent(event *AuthEvent) error
+}
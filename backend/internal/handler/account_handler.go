@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AccountHandler handles account export and deletion HTTP requests
+type AccountHandler struct {
+	accountService domain.AccountService
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(accountService domain.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// Export downloads a zip archive of everything stored about the user,
+// including copies of their images
+func (h *AccountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Build the archive before writing so a failure can still be reported
+	var buf bytes.Buffer
+	if err := h.accountService.ExportAccount(user.ID, &buf); err != nil {
+		http.Error(w, "Failed to export account", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the archive
+	filename := fmt.Sprintf("lilo-account-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// RequestDeletion schedules the user's account for deletion after the grace period
+func (h *AccountHandler) RequestDeletion(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Schedule deletion
+	deletion, err := h.accountService.RequestDeletion(user.ID)
+	if errors.Is(err, domain.ErrDeletionPending) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to schedule account deletion", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the scheduled deletion
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account deletion scheduled",
+		"data":    deletion,
+	})
+}
+
+// GetDeletion returns the status of the user's account deletion request
+func (h *AccountHandler) GetDeletion(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get deletion
+	deletion, err := h.accountService.GetDeletion(user.ID)
+	if errors.Is(err, domain.ErrDeletionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get account deletion", http.StatusInternalServerError)
+		return
+	}
+
+	// Return deletion
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": deletion,
+	})
+}
+
+// CancelDeletion keeps the user's account while its deletion is still pending
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Cancel deletion
+	deletion, err := h.accountService.CancelDeletion(user.ID)
+	if errors.Is(err, domain.ErrDeletionNotFound) {
+		http.Error(w, "No pending account deletion", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to cancel account deletion", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the cancelled deletion
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Account deletion cancelled",
+		"data":    deletion,
+	})
+}
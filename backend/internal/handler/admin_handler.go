@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AdminHandler handles support and taxonomy HTTP requests. Routes are guarded
+// by RequirePermission, so handlers only check that a user is present.
+type AdminHandler struct {
+	adminService domain.AdminService
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(adminService domain.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// ListUsers lists and searches users
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters for filters
+	params := r.URL.Query()
+	query := domain.UserQuery{
+		Search: params.Get("q"),
+		Role:   domain.Role(params.Get("role")),
+	}
+	if raw := params.Get("disabled"); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "disabled must be true or false", http.StatusBadRequest)
+			return
+		}
+		query.Disabled = &disabled
+	}
+
+	// Parse pagination parameters
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get users
+	users, pageInfo, err := h.adminService.ListUsers(query, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return one page of users
+	writePage(w, r, users, pageInfo)
+}
+
+// GetUser returns any user's profile
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	// Get user
+	user, err := h.adminService.GetUser(r.PathValue("id"))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	// Return user
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": user,
+	})
+}
+
+// GetUserItems returns one page of a user's wardrobe, with the same filters as
+// the user's own item list
+func (h *AdminHandler) GetUserItems(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters for filters
+	query, err := parseWardrobeQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Parse pagination parameters
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get items
+	items, pageInfo, err := h.adminService.GetUserItems(r.PathValue("id"), query, page)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get wardrobe items", http.StatusInternalServerError)
+		return
+	}
+
+	// Return one page of items
+	writePage(w, r, items, pageInfo)
+}
+
+// DisableUser turns a user away at sign-in until they're re-enabled
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+// EnableUser lets a disabled user sign in again
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	// Get admin from context
+	admin, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Update user
+	user, err := h.adminService.SetUserDisabled(admin.ID, r.PathValue("id"), disabled)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, domain.ErrDisableSelf) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	// Return updated user
+	message := "User enabled"
+	if disabled {
+		message = "User disabled"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    user,
+	})
+}
+
+// GetCategories returns the built-in categories shared by every user
+func (h *AdminHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	// Get categories
+	categories, err := h.adminService.ListCategories()
+	if err != nil {
+		http.Error(w, "Failed to get categories", http.StatusInternalServerError)
+		return
+	}
+
+	// Return categories
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": categories,
+	})
+}
+
+// CreateCategory adds a built-in category
+func (h *AdminHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var req struct {
+		Name          string   `json:"name"`
+		Subcategories []string `json:"subcategories"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Create category
+	category, err := h.adminService.CreateCategory(req.Name, req.Subcategories)
+	if err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return created category
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Category created successfully",
+		"data":    category,
+	})
+}
+
+// UpdateCategory renames a built-in category and replaces its subcategories
+func (h *AdminHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var req struct {
+		Name          string   `json:"name"`
+		Subcategories []string `json:"subcategories"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update category
+	category, err := h.adminService.UpdateCategory(r.PathValue("id"), req.Name, req.Subcategories)
+	if err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return updated category
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Category updated successfully",
+		"data":    category,
+	})
+}
+
+// DeleteCategory removes a built-in category
+func (h *AdminHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	// Delete category
+	if err := h.adminService.DeleteCategory(r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Category deleted successfully",
+	})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AnalyticsHandler handles wardrobe analytics HTTP requests
+type AnalyticsHandler struct {
+	analyticsService domain.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler
+func NewAnalyticsHandler(analyticsService domain.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetWardrobeAnalytics returns cost-per-wear, utilization and distribution figures
+// for the authenticated user's wardrobe
+func (h *AnalyticsHandler) GetWardrobeAnalytics(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse optional unworn window
+	unwornDays := 0
+	if daysStr := r.URL.Query().Get("unwornDays"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 1 {
+			http.Error(w, "Invalid unwornDays", http.StatusBadRequest)
+			return
+		}
+		unwornDays = days
+	}
+
+	// Get analytics
+	analytics, err := h.analyticsService.GetWardrobeAnalytics(user.ID, unwornDays)
+	if err != nil {
+		http.Error(w, "Failed to get wardrobe analytics", http.StatusInternalServerError)
+		return
+	}
+
+	// Return analytics
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": analytics,
+	})
+}
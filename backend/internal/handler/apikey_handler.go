@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// APIKeyHandler handles API key HTTP requests
+type APIKeyHandler struct {
+	apiKeyService domain.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(apiKeyService domain.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ListKeys returns the user's API keys with when each was last used
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get keys
+	keys, err := h.apiKeyService.ListKeys(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to get API keys", http.StatusInternalServerError)
+		return
+	}
+
+	// Return keys
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": keys,
+	})
+}
+
+// CreateKey mints an API key. Its secret is in this response only.
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Name          string         `json:"name"`
+		Scopes        []domain.Scope `json:"scopes"`
+		ExpiresInDays int            `json:"expiresInDays"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Create key
+	lifetime := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, secret, err := h.apiKeyService.CreateKey(user.ID, req.Name, req.Scopes, lifetime)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return created key with its secret
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "API key created. Copy the secret now; it won't be shown again",
+		"data": struct {
+			*domain.APIKey
+			Secret string `json:"secret"`
+		}{key, secret},
+	})
+}
+
+// RevokeKey stops an API key from working
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Revoke key
+	key, err := h.apiKeyService.RevokeKey(user.ID, r.PathValue("id"))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	// Return revoked key
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "API key revoked",
+		"data":    key,
+	})
+}
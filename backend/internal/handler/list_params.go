@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// parsePageRequest reads the cursor, limit, sort and order query parameters
+// shared by list endpoints
+func parsePageRequest(r *http.Request) (domain.PageRequest, error) {
+	query := r.URL.Query()
+	page := domain.PageRequest{
+		Cursor: query.Get("cursor"),
+		SortBy: domain.SortField(query.Get("sort")),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > domain.MaxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", domain.MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if page.SortBy != "" && !page.SortBy.IsValid() {
+		return page, fmt.Errorf("invalid sort field: %s", page.SortBy)
+	}
+
+	switch order := query.Get("order"); order {
+	case "":
+		// Newest first unless a field is named, which sorts ascending
+		page.Descending = page.SortBy == ""
+	case "asc":
+		page.Descending = false
+	case "desc":
+		page.Descending = true
+	default:
+		return page, fmt.Errorf("order must be 'asc' or 'desc'")
+	}
+	if page.SortBy == "" {
+		page.SortBy = domain.SortByCreatedAt
+	}
+
+	return page, nil
+}
+
+// parseFields reads the optional comma-separated fields query parameter
+func parseFields(r *http.Request) []string {
+	var fields []string
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// projectFields reduces each record to the requested JSON fields. The id is
+// always kept so clients can follow up on a record. With no fields requested the
+// records are returned unchanged.
+func projectFields[T any](records []T, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return records, nil
+	}
+
+	keep := map[string]bool{"id": true}
+	for _, field := range fields {
+		keep[field] = true
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]json.RawMessage
+		if err := json.Unmarshal(data, &full); err != nil {
+			return nil, err
+		}
+		for key := range full {
+			if !keep[key] {
+				delete(full, key)
+			}
+		}
+		projected = append(projected, full)
+	}
+	return projected, nil
+}
+
+// writePage writes one page of a list endpoint with its pagination info
+func writePage[T any](w http.ResponseWriter, r *http.Request, records []T, pageInfo *domain.PageInfo) {
+	data, err := projectFields(records, parseFields(r))
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":       data,
+		"pagination": pageInfo,
+	})
+}
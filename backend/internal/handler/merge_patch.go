@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// mergePatchContentType is the media type of RFC 7396 JSON merge patches
+const mergePatchContentType = "application/merge-patch+json"
+
+var (
+	errUnsupportedPatchType = errors.New("content type must be " + mergePatchContentType)
+	errInvalidPatch         = errors.New("merge patch must be a JSON object")
+)
+
+// readMergePatch reads a merge patch document from the request body. Plain
+// application/json is accepted too, since many clients can't set a custom type.
+func readMergePatch(r *http.Request) (map[string]interface{}, error) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+			return nil, errUnsupportedPatchType
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errInvalidPatch
+	}
+	var patch map[string]interface{}
+	if err := decodeJSON(body, &patch); err != nil || patch == nil {
+		return nil, errInvalidPatch
+	}
+	return patch, nil
+}
+
+// applyMergePatch returns a copy of current with patch applied following RFC
+// 7396: members set to null are removed, objects merge recursively, and any
+// other value replaces what was there.
+func applyMergePatch[T any](current *T, patch map[string]interface{}) (*T, error) {
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var target interface{}
+	if err := decodeJSON(data, &target); err != nil {
+		return nil, err
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// decodeJSON decodes keeping numbers exact so large integers survive the round trip
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// patchErrorStatus maps merge patch read errors to HTTP status codes
+func patchErrorStatus(err error) int {
+	if errors.Is(err, errUnsupportedPatchType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
@@ -2,8 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/lilo/backend/internal/domain"
 )
@@ -30,38 +30,32 @@ func (h *OutfitHandler) GetOutfits(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse query parameters for filters
-	filters := make(map[string]interface{})
-
-	if occasion := r.URL.Query().Get("occasion"); occasion != "" {
-		filters["occasion"] = occasion
-	}
-	if season := r.URL.Query().Get("season"); season != "" {
-		filters["season"] = season
-	}
-	if isFavoriteStr := r.URL.Query().Get("isFavorite"); isFavoriteStr != "" {
-		if isFavorite, err := strconv.ParseBool(isFavoriteStr); err == nil {
-			filters["isFavorite"] = isFavorite
-		}
+	query, err := parseOutfitQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if isRecommendedStr := r.URL.Query().Get("isRecommended"); isRecommendedStr != "" {
-		if isRecommended, err := strconv.ParseBool(isRecommendedStr); err == nil {
-			filters["isRecommended"] = isRecommended
-		}
+
+	// Parse pagination parameters
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Get outfits
-	outfits, err := h.outfitService.GetUserOutfits(user.ID, filters)
+	outfits, pageInfo, err := h.outfitService.GetUserOutfits(user.ID, query, page)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to get outfits", http.StatusInternalServerError)
 		return
 	}
 
-	// Return outfits
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data": outfits,
-	})
+	// Return one page of outfits
+	writePage(w, r, outfits, pageInfo)
 }
 
 // CreateOutfit creates a new outfit
@@ -90,6 +84,7 @@ func (h *OutfitHandler) CreateOutfit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return created outfit
+	setETag(w, outfit.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -121,7 +116,13 @@ func (h *OutfitHandler) GetOutfit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Skip the body if the client's copy is current
+	if notModified(w, r, outfit.Version) {
+		return
+	}
+
 	// Return outfit
+	setETag(w, outfit.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -156,13 +157,105 @@ func (h *OutfitHandler) UpdateOutfit(w http.ResponseWriter, r *http.Request) {
 	outfit.ID = outfitID
 	outfit.UserID = user.ID
 
+	// An If-Match precondition takes priority over the version in the body
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		outfit.Version = version
+	}
+
+	// Update outfit
+	err := h.outfitService.UpdateOutfit(&outfit)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return updated outfit
+	setETag(w, outfit.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Outfit updated successfully",
+		"data":    outfit,
+	})
+}
+
+// PatchOutfit applies a JSON merge patch to an outfit
+func (h *OutfitHandler) PatchOutfit(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get outfit ID from URL path
+	outfitID := r.PathValue("id")
+	if outfitID == "" {
+		http.Error(w, "Outfit ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	patch, err := readMergePatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	// Get outfit
+	existing, err := h.outfitService.GetOutfit(outfitID)
+	if err != nil {
+		http.Error(w, "Outfit not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns the outfit
+	if existing.UserID != user.ID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	// Apply the patch to the current outfit
+	outfit, err := applyMergePatch(existing, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	outfit.ID = existing.ID
+	outfit.UserID = existing.UserID
+
+	// An If-Match precondition takes priority over the version the patch was applied to
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		outfit.Version = version
+	}
+
 	// Update outfit
-	if err := h.outfitService.UpdateOutfit(&outfit); err != nil {
+	err = h.outfitService.UpdateOutfit(outfit)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Return updated outfit
+	setETag(w, outfit.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// parseWardrobeQuery reads item filters from the query string. Multi-value
+// filters take comma-separated or repeated values (color=red,navy) and are
+// negated with a trailing bang on the name (color!=red). The query is validated
+// here; services take it as given.
+func parseWardrobeQuery(r *http.Request) (domain.WardrobeQuery, error) {
+	params := r.URL.Query()
+	query := domain.WardrobeQuery{
+		Category:   stringFilter(params, "category"),
+		Color:      stringFilter(params, "color"),
+		Season:     stringFilter(params, "season"),
+		Status:     stringFilter(params, "status"),
+		Material:   stringFilter(params, "material"),
+		Pattern:    stringFilter(params, "pattern"),
+		Fit:        stringFilter(params, "fit"),
+		Waterproof: stringFilter(params, "waterproof"),
+		Care:       stringFilter(params, "care"),
+	}
+
+	var err error
+	if query.IsOwned, err = boolParam(params, "isOwned"); err != nil {
+		return query, err
+	}
+	if query.Formality, err = intRange(params, "minFormality", "maxFormality"); err != nil {
+		return query, err
+	}
+	if query.Warmth, err = intRange(params, "minWarmth", "maxWarmth"); err != nil {
+		return query, err
+	}
+	if query.Created, err = timeRange(params, "createdAfter", "createdBefore"); err != nil {
+		return query, err
+	}
+	if query.Purchased, err = timeRange(params, "purchasedAfter", "purchasedBefore"); err != nil {
+		return query, err
+	}
+	if query.LastWorn, err = timeRange(params, "wornAfter", "wornBefore"); err != nil {
+		return query, err
+	}
+
+	return query, query.Validate()
+}
+
+// parseOutfitQuery reads outfit filters from the query string, using the same
+// conventions as parseWardrobeQuery
+func parseOutfitQuery(r *http.Request) (domain.OutfitQuery, error) {
+	params := r.URL.Query()
+	query := domain.OutfitQuery{
+		Occasion: stringFilter(params, "occasion"),
+		Season:   stringFilter(params, "season"),
+		Item:     stringFilter(params, "item"),
+	}
+
+	var err error
+	if query.IsFavorite, err = boolParam(params, "isFavorite"); err != nil {
+		return query, err
+	}
+	if query.IsRecommended, err = boolParam(params, "isRecommended"); err != nil {
+		return query, err
+	}
+	if query.Created, err = timeRange(params, "createdAfter", "createdBefore"); err != nil {
+		return query, err
+	}
+	if query.LastWorn, err = timeRange(params, "wornAfter", "wornBefore"); err != nil {
+		return query, err
+	}
+
+	return query, query.Validate()
+}
+
+// stringFilter collects the included values of key and the excluded values of key!
+func stringFilter(params url.Values, key string) domain.StringFilter {
+	return domain.StringFilter{
+		Include: splitValues(params[key]),
+		Exclude: splitValues(params[key+"!"]),
+	}
+}
+
+func splitValues(raw []string) []string {
+	var values []string
+	for _, r := range raw {
+		for _, v := range strings.Split(r, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func boolParam(params url.Values, key string) (*bool, error) {
+	raw := params.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return &value, nil
+}
+
+func intRange(params url.Values, minKey, maxKey string) (domain.IntRange, error) {
+	var rng domain.IntRange
+	for key, bound := range map[string]*int{minKey: &rng.Min, maxKey: &rng.Max} {
+		raw := params.Get(key)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return rng, fmt.Errorf("%s must be a number", key)
+		}
+		*bound = value
+	}
+	return rng, nil
+}
+
+// timeRange reads RFC 3339 timestamps or plain dates. A plain date as the
+// upper bound covers the whole day.
+func timeRange(params url.Values, fromKey, toKey string) (domain.TimeRange, error) {
+	var rng domain.TimeRange
+	if raw := params.Get(fromKey); raw != "" {
+		t, _, err := parseTime(raw)
+		if err != nil {
+			return rng, fmt.Errorf("%s must be a date or RFC 3339 timestamp", fromKey)
+		}
+		rng.From = &t
+	}
+	if raw := params.Get(toKey); raw != "" {
+		t, dateOnly, err := parseTime(raw)
+		if err != nil {
+			return rng, fmt.Errorf("%s must be a date or RFC 3339 timestamp", toKey)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		rng.To = &t
+	}
+	return rng, nil
+}
+
+func parseTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -54,33 +55,32 @@ func (h *RecommendationHandler) GetExplore(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Parse query parameters for filters
-	filters := make(map[string]interface{})
-
-	if occasion := r.URL.Query().Get("occasion"); occasion != "" {
-		filters["occasion"] = occasion
-	}
-	if season := r.URL.Query().Get("season"); season != "" {
-		filters["season"] = season
+	query, err := parseOutfitQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if isFavoriteStr := r.URL.Query().Get("isFavorite"); isFavoriteStr != "" {
-		if isFavorite, err := strconv.ParseBool(isFavoriteStr); err == nil {
-			filters["isFavorite"] = isFavorite
-		}
+
+	// Parse pagination parameters
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Get explore recommendations
-	recommendations, err := h.recommendationService.GetExploreRecommendations(user.ID, filters)
+	recommendations, pageInfo, err := h.recommendationService.GetExploreRecommendations(user.ID, query, page)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to get explore recommendations", http.StatusInternalServerError)
 		return
 	}
 
-	// Return recommendations
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data": recommendations,
-	})
+	// Return one page of recommendations
+	writePage(w, r, recommendations, pageInfo)
 }
 
 // SubmitFeedback submits feedback for a recommendation
@@ -116,3 +116,73 @@ func (h *RecommendationHandler) SubmitFeedback(w http.ResponseWriter, r *http.Re
 		"message": "Feedback submitted successfully",
 	})
 }
+
+// GetGaps returns the wardrobe gaps that would unlock the most new outfits
+func (h *RecommendationHandler) GetGaps(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse optional limit
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// Get gaps
+	gaps, err := h.recommendationService.GetWardrobeGaps(user.ID, limit)
+	if err != nil {
+		http.Error(w, "Failed to analyze wardrobe gaps", http.StatusInternalServerError)
+		return
+	}
+
+	// Return gaps
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": gaps,
+	})
+}
+
+// AddGapsToWishlist adds selected wardrobe gap suggestions to the user's wishlist
+func (h *RecommendationHandler) AddGapsToWishlist(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		GapIDs []string `json:"gapIds"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Add gaps to wishlist
+	items, err := h.recommendationService.AddGapsToWishlist(user.ID, req.GapIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return created wishlist items
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Items added to wishlist successfully",
+		"data":    items,
+	})
+}
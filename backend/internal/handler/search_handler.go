@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// SearchHandler handles search HTTP requests
+type SearchHandler struct {
+	searchService domain.SearchService
+}
+
+// NewSearchHandler creates a new SearchHandler
+func NewSearchHandler(searchService domain.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search runs a keyword search over the authenticated user's items and outfits
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse query parameters
+	query := &domain.SearchQuery{
+		UserID:   user.ID,
+		Text:     r.URL.Query().Get("q"),
+		Category: r.URL.Query().Get("category"),
+		Color:    r.URL.Query().Get("color"),
+		Season:   r.URL.Query().Get("season"),
+		Occasion: r.URL.Query().Get("occasion"),
+	}
+	if types := r.URL.Query().Get("type"); types != "" {
+		query.Types = strings.Split(types, ",")
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	// Search
+	results, err := h.searchService.Search(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return results
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": results,
+	})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// SessionHandler handles session listing and sign-out HTTP requests
+type SessionHandler struct {
+	sessionService domain.SessionService
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(sessionService domain.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// ListSessions returns the user's active sessions, marking the one making the request
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get sessions
+	sessions, err := h.sessionService.ListSessions(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+		return
+	}
+	currentID, _ := r.Context().Value(domain.ContextKeySessionID).(string)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+
+	// Return sessions
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": sessions,
+	})
+}
+
+// RevokeSession signs out one of the user's sessions
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Revoke session
+	err := h.sessionService.RevokeSession(user.ID, r.PathValue("id"))
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	// Return success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Session signed out",
+	})
+}
+
+// RevokeAllSessions signs the user out everywhere, including this session
+func (h *SessionHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	h.signOut(w, r, domain.SignOutGlobal)
+}
+
+// SignOut signs out the session making the request. The scope query parameter
+// widens it to every session ("global") or every other session ("others").
+func (h *SessionHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		scope = domain.SignOutLocal
+	}
+	h.signOut(w, r, scope)
+}
+
+func (h *SessionHandler) signOut(w http.ResponseWriter, r *http.Request, scope string) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+	currentID, _ := r.Context().Value(domain.ContextKeySessionID).(string)
+
+	// Revoke sessions
+	revoked := 0
+	var err error
+	switch scope {
+	case domain.SignOutLocal:
+		if currentID == "" {
+			http.Error(w, "Token does not belong to a session", http.StatusBadRequest)
+			return
+		}
+		if err = h.sessionService.RevokeSession(user.ID, currentID); err == nil {
+			revoked = 1
+		}
+	case domain.SignOutGlobal:
+		revoked, err = h.sessionService.RevokeAllSessions(user.ID, "")
+	case domain.SignOutOthers:
+		if currentID == "" {
+			http.Error(w, "Token does not belong to a session", http.StatusBadRequest)
+			return
+		}
+		revoked, err = h.sessionService.RevokeAllSessions(user.ID, currentID)
+	default:
+		http.Error(w, "scope must be 'local', 'global' or 'others'", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to sign out", http.StatusInternalServerError)
+		return
+	}
+
+	// Return how many sessions were signed out
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Signed out",
+		"data":    map[string]int{"revoked": revoked},
+	})
+}
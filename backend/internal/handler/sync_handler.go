@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// SyncHandler handles offline sync HTTP requests
+type SyncHandler struct {
+	syncService domain.SyncService
+}
+
+// NewSyncHandler creates a new SyncHandler
+func NewSyncHandler(syncService domain.SyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// Pull returns the authenticated user's changes since a sync token
+func (h *SyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse query parameters
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	// Get changes
+	pull, err := h.syncService.Pull(user.ID, r.URL.Query().Get("since"), limit)
+	if errors.Is(err, domain.ErrInvalidSyncToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get changes", http.StatusInternalServerError)
+		return
+	}
+
+	// Return changes
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": pull,
+	})
+}
+
+// Push applies a batch of changes the authenticated user made offline
+func (h *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Mutations []*domain.SyncMutation `json:"mutations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	for _, mutation := range req.Mutations {
+		if mutation == nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Apply mutations
+	results, err := h.syncService.Push(user.ID, req.Mutations)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return per-mutation results
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": results,
+	})
+}
@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/internal/repository"
+	"github.com/lilo/backend/internal/service"
+)
+
+// syncFixture wires SyncHandler to tracked in-memory repositories, as main does
+type syncFixture struct {
+	wardrobe domain.WardrobeService
+	handler  *SyncHandler
+	user     *domain.User
+}
+
+func newSyncFixture(t *testing.T) *syncFixture {
+	t.Helper()
+	changeLog := repository.NewChangeLog()
+	userRepo := repository.NewTrackedUserRepository(repository.NewUserRepository(), changeLog)
+	wardrobeRepo := repository.NewTrackedWardrobeRepository(repository.NewWardrobeRepository(), changeLog)
+	outfitRepo := repository.NewTrackedOutfitRepository(repository.NewOutfitRepository(), changeLog)
+
+	userService := service.NewUserService(userRepo)
+	wardrobeService := service.NewWardrobeService(wardrobeRepo)
+	outfitService := service.NewOutfitService(outfitRepo, wardrobeRepo)
+
+	user := &domain.User{SupabaseID: "supabase-1", Email: "user@example.com"}
+	if err := userRepo.Create(user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return &syncFixture{
+		wardrobe: wardrobeService,
+		handler:  NewSyncHandler(service.NewSyncService(changeLog, userService, wardrobeService, outfitService)),
+		user:     user,
+	}
+}
+
+// addItem saves an item for userID through the wardrobe service
+func (f *syncFixture) addItem(t *testing.T, userID, name string) *domain.ClothingItem {
+	t.Helper()
+	item := &domain.ClothingItem{UserID: userID, Name: name, Category: "tops", Color: "black", IsOwned: true}
+	if err := f.wardrobe.AddItem(item); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return item
+}
+
+func (f *syncFixture) pull(t *testing.T, since string, limit string) (int, *domain.SyncPull) {
+	t.Helper()
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+	}
+	if limit != "" {
+		query.Set("limit", limit)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/sync?"+query.Encode(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), domain.ContextKeyUser, f.user))
+	rec := httptest.NewRecorder()
+	f.handler.Pull(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+
+	var resp struct {
+		Data domain.SyncPull `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode pull: %v", err)
+	}
+	return rec.Code, &resp.Data
+}
+
+func (f *syncFixture) push(t *testing.T, mutations ...*domain.SyncMutation) []*domain.SyncResult {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"mutations": mutations})
+	if err != nil {
+		t.Fatalf("encode push: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/sync", bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), domain.ContextKeyUser, f.user))
+	rec := httptest.NewRecorder()
+	f.handler.Push(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("push status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp struct {
+		Data []*domain.SyncResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode push: %v", err)
+	}
+	if len(resp.Data) != len(mutations) {
+		t.Fatalf("results = %d, want %d", len(resp.Data), len(mutations))
+	}
+	return resp.Data
+}
+
+// wantChange is a change expected in a pull, identified by type and ID
+type wantChange struct {
+	Type    string
+	ID      string
+	Deleted bool
+}
+
+func checkChanges(t *testing.T, got []*domain.SyncChange, want []wantChange) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("changes = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, change := range got {
+		if change.Type != want[i].Type || change.ID != want[i].ID || change.Deleted != want[i].Deleted {
+			t.Errorf("change %d = %s %s deleted=%v, want %s %s deleted=%v",
+				i, change.Type, change.ID, change.Deleted, want[i].Type, want[i].ID, want[i].Deleted)
+		}
+		if change.Type == domain.SyncTypeItem && (change.Item == nil) != change.Deleted {
+			t.Errorf("change %d: item attached = %v, deleted = %v", i, change.Item != nil, change.Deleted)
+		}
+	}
+}
+
+func TestSyncPullDeltas(t *testing.T) {
+	f := newSyncFixture(t)
+	shirt := f.addItem(t, f.user.ID, "Shirt")
+	scarf := f.addItem(t, f.user.ID, "Scarf")
+	f.addItem(t, "someone-else", "Coat")
+
+	// Each step pulls from the token the previous one returned
+	steps := []struct {
+		name   string
+		change func(t *testing.T)
+		want   []wantChange
+	}{
+		{
+			name: "first pull sends everything",
+			want: []wantChange{
+				{Type: domain.SyncTypeItem, ID: shirt.ID},
+				{Type: domain.SyncTypeItem, ID: scarf.ID},
+			},
+		},
+		{
+			name: "nothing changed",
+		},
+		{
+			name: "an update and a delete",
+			change: func(t *testing.T) {
+				updated := *shirt
+				updated.Name = "Linen shirt"
+				if err := f.wardrobe.UpdateItem(&updated); err != nil {
+					t.Fatalf("UpdateItem: %v", err)
+				}
+				if err := f.wardrobe.DeleteItem(scarf.ID); err != nil {
+					t.Fatalf("DeleteItem: %v", err)
+				}
+			},
+			want: []wantChange{
+				{Type: domain.SyncTypeItem, ID: shirt.ID},
+				{Type: domain.SyncTypeItem, ID: scarf.ID, Deleted: true},
+			},
+		},
+		{
+			name: "other users' changes aren't sent",
+			change: func(t *testing.T) {
+				f.addItem(t, "someone-else", "Hat")
+				updated, err := f.wardrobe.GetItem(shirt.ID)
+				if err != nil {
+					t.Fatalf("GetItem: %v", err)
+				}
+				copied := *updated
+				copied.Notes = "Dry clean"
+				if err := f.wardrobe.UpdateItem(&copied); err != nil {
+					t.Fatalf("UpdateItem: %v", err)
+				}
+			},
+			want: []wantChange{
+				{Type: domain.SyncTypeItem, ID: shirt.ID},
+			},
+		},
+	}
+
+	token := ""
+	for _, step := range steps {
+		if step.change != nil {
+			step.change(t)
+		}
+		code, pull := f.pull(t, token, "")
+		if code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", step.name, code, http.StatusOK)
+		}
+		t.Run(step.name, func(t *testing.T) {
+			checkChanges(t, pull.Changes, step.want)
+			if pull.HasMore {
+				t.Error("hasMore = true, want false")
+			}
+			if len(step.want) == 0 && pull.Token != token && token != "" {
+				t.Errorf("token moved from %q to %q with no changes", token, pull.Token)
+			}
+		})
+		token = pull.Token
+	}
+
+	// The latest copy is what's sent, not the one at the time of the change
+	_, pull := f.pull(t, "", "")
+	for _, change := range pull.Changes {
+		if change.ID == shirt.ID && (change.Item == nil || change.Item.Name != "Linen shirt") {
+			t.Errorf("pulled item = %+v, want the current copy", change.Item)
+		}
+	}
+}
+
+func TestSyncPullPages(t *testing.T) {
+	f := newSyncFixture(t)
+	for _, name := range []string{"Shirt", "Scarf", "Coat"} {
+		f.addItem(t, f.user.ID, name)
+	}
+
+	token, pulled := "", 0
+	for pages := 1; ; pages++ {
+		code, pull := f.pull(t, token, "2")
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", code, http.StatusOK)
+		}
+		if len(pull.Changes) > 2 {
+			t.Fatalf("page %d has %d changes, want at most 2", pages, len(pull.Changes))
+		}
+		pulled += len(pull.Changes)
+		token = pull.Token
+		if !pull.HasMore {
+			break
+		}
+		if pages > 3 {
+			t.Fatal("pull never finished")
+		}
+	}
+	if pulled != 3 {
+		t.Errorf("pulled %d changes, want 3", pulled)
+	}
+}
+
+func TestSyncPullRejectsBadParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		since string
+		limit string
+	}{
+		{name: "token that isn't a number", since: "abc"},
+		{name: "negative token", since: "-1"},
+		{name: "zero limit", limit: "0"},
+		{name: "limit that isn't a number", limit: "ten"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t)
+			if code, _ := f.pull(t, tt.since, tt.limit); code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestSyncPush(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup returns the mutation to push
+		setup       func(t *testing.T, f *syncFixture) *domain.SyncMutation
+		wantStatus  string
+		wantVersion int64 // version of the item in the result, when there is one
+	}{
+		{
+			name: "item created offline",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				return &domain.SyncMutation{
+					Type: domain.SyncTypeItem, Op: domain.SyncOpUpsert, ID: "offline-1",
+					Item: &domain.ClothingItem{Name: "Shirt", Category: "tops", Color: "white", IsOwned: true},
+				}
+			},
+			wantStatus:  domain.SyncStatusApplied,
+			wantVersion: 1,
+		},
+		{
+			name: "update of the current version",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				item := f.addItem(t, f.user.ID, "Shirt")
+				edited := *item
+				edited.Name = "Linen shirt"
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpUpsert, ID: item.ID, BaseVersion: item.Version, Item: &edited}
+			},
+			wantStatus:  domain.SyncStatusApplied,
+			wantVersion: 2,
+		},
+		{
+			name: "update of a stale version conflicts",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				item := f.addItem(t, f.user.ID, "Shirt")
+				edited := *item
+				edited.Name = "Edited online"
+				if err := f.wardrobe.UpdateItem(&edited); err != nil {
+					t.Fatalf("UpdateItem: %v", err)
+				}
+				offline := *item
+				offline.Name = "Edited offline"
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpUpsert, ID: item.ID, BaseVersion: 1, Item: &offline}
+			},
+			wantStatus:  domain.SyncStatusConflict,
+			wantVersion: 2,
+		},
+		{
+			name: "delete of a stale version conflicts",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				item := f.addItem(t, f.user.ID, "Shirt")
+				edited := *item
+				edited.Name = "Edited online"
+				if err := f.wardrobe.UpdateItem(&edited); err != nil {
+					t.Fatalf("UpdateItem: %v", err)
+				}
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpDelete, ID: item.ID, BaseVersion: 1}
+			},
+			wantStatus:  domain.SyncStatusConflict,
+			wantVersion: 2,
+		},
+		{
+			name: "delete of a record that's already gone",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpDelete, ID: "missing"}
+			},
+			wantStatus: domain.SyncStatusApplied,
+		},
+		{
+			name: "another user's item",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				item := f.addItem(t, "someone-else", "Coat")
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpDelete, ID: item.ID}
+			},
+			wantStatus: domain.SyncStatusRejected,
+		},
+		{
+			name: "item that fails validation",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				return &domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpUpsert, ID: "offline-2", Item: &domain.ClothingItem{Name: "Shirt"}}
+			},
+			wantStatus: domain.SyncStatusRejected,
+		},
+		{
+			name: "unknown record type",
+			setup: func(t *testing.T, f *syncFixture) *domain.SyncMutation {
+				return &domain.SyncMutation{Type: "closet", Op: domain.SyncOpUpsert, ID: "x"}
+			},
+			wantStatus: domain.SyncStatusRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t)
+			mutation := tt.setup(t, f)
+			result := f.push(t, mutation)[0]
+
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %q, want %q (%s)", result.Status, tt.wantStatus, result.Error)
+			}
+			if tt.wantVersion == 0 {
+				return
+			}
+			// Conflicts carry the server's copy so the client can resolve them
+			if result.Item == nil {
+				t.Fatal("result has no item")
+			}
+			if result.Item.Version != tt.wantVersion {
+				t.Errorf("item version = %d, want %d", result.Item.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestSyncPushThenPull(t *testing.T) {
+	f := newSyncFixture(t)
+	_, pull := f.pull(t, "", "")
+	token := pull.Token
+
+	results := f.push(t,
+		&domain.SyncMutation{
+			Type: domain.SyncTypeItem, Op: domain.SyncOpUpsert, ID: "offline-1",
+			Item: &domain.ClothingItem{Name: "Shirt", Category: "tops", Color: "white", IsOwned: true},
+		},
+		&domain.SyncMutation{Type: domain.SyncTypeItem, Op: domain.SyncOpDelete, ID: "offline-1"},
+	)
+	for i, result := range results {
+		if result.Status != domain.SyncStatusApplied {
+			t.Fatalf("mutation %d: status = %q (%s)", i, result.Status, result.Error)
+		}
+	}
+
+	// Only the latest change to a record is kept, so another device just sees the tombstone
+	_, pull = f.pull(t, token, "")
+	checkChanges(t, pull.Changes, []wantChange{{Type: domain.SyncTypeItem, ID: "offline-1", Deleted: true}})
+}
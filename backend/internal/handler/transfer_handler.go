@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// maxImportBytes caps the size of an uploaded import file
+const maxImportBytes = 10 << 20
+
+// ImportItems adds items to the user's wardrobe from a CSV or JSON file. The
+// file is the request body, or the "file" field of a multipart form.
+func (h *WardrobeHandler) ImportItems(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse query parameters
+	mapping, err := parseColumnMapping(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := domain.ImportOptions{
+		Format:  strings.ToLower(r.URL.Query().Get("format")),
+		Mapping: mapping,
+		DryRun:  r.URL.Query().Get("dryRun") == "true",
+	}
+
+	// Read the file, inferring its format when not given
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	file, format, err := importFile(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if opts.Format == "" {
+		opts.Format = format
+	}
+
+	// Import items
+	report, err := h.wardrobeService.ImportItems(user.ID, file, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge), errors.Is(err, domain.ErrImportTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, domain.ErrUnsupportedFormat), errors.Is(err, domain.ErrInvalidImport):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to import items", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Return the per-row report
+	message := "Import complete"
+	if report.DryRun {
+		message = "Dry run complete; nothing was imported"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    report,
+	})
+}
+
+// parseColumnMapping reads map=Source:field pairs, given as repeated
+// parameters or comma separated
+func parseColumnMapping(r *http.Request) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, param := range r.URL.Query()["map"] {
+		for _, pair := range strings.Split(param, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			source, field, ok := strings.Cut(pair, ":")
+			if !ok || strings.TrimSpace(source) == "" || strings.TrimSpace(field) == "" {
+				return nil, fmt.Errorf("invalid column mapping %q: expected Source:field", pair)
+			}
+			mapping[strings.TrimSpace(source)] = strings.TrimSpace(field)
+		}
+	}
+	return mapping, nil
+}
+
+// importFile returns the uploaded file and the format its type or name suggests
+func importFile(r *http.Request) (io.Reader, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, formatFromMediaType(mediaType), nil
+	}
+
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		return nil, "", err
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, "", errors.New("file is required")
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	if format != domain.FormatCSV && format != domain.FormatJSON {
+		partType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+		format = formatFromMediaType(partType)
+	}
+	return file, format, nil
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return domain.FormatCSV
+	case "application/json":
+		return domain.FormatJSON
+	}
+	return ""
+}
+
+// ExportHandler handles data export HTTP requests
+type ExportHandler struct {
+	exportService domain.ExportService
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(exportService domain.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// Export downloads the user's wardrobe, outfits and reflections as JSON, or as
+// a zip of CSV files with format=csv
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse query parameters
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = domain.FormatJSON
+	}
+
+	// Build the export before writing so a failure can still be reported
+	var buf bytes.Buffer
+	err := h.exportService.WriteExport(user.ID, format, &buf)
+	if errors.Is(err, domain.ErrUnsupportedFormat) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to export data", http.StatusInternalServerError)
+		return
+	}
+
+	// Return the file
+	contentType, extension := "application/json", "json"
+	if format == domain.FormatCSV {
+		contentType, extension = "application/zip", "zip"
+	}
+	filename := fmt.Sprintf("lilo-export-%s.%s", time.Now().Format("2006-01-02"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
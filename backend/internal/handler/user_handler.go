@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/lilo/backend/internal/domain"
@@ -19,31 +20,14 @@ func NewUserHandler(userService domain.UserService) *UserHandler {
 	}
 }
 
-// SignUp handles user registration from Supabase
+// SignUp confirms registration after a Supabase sign-up. The account is
+// provisioned by the auth middleware from the verified token's claims, never
+// from the request body, so an email can't be claimed without owning it.
 func (h *UserHandler) SignUp(w http.ResponseWriter, r *http.Request) {
-	// Parse request body
-	var req struct {
-		SupabaseID string `json:"supabaseId"`
-		Email      string `json:"email"`
-		Name       string `json:"name"`
-		Picture    string `json:"picture"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Create user in our database
-	user := &domain.User{
-		SupabaseID: req.SupabaseID,
-		Email:      req.Email,
-		Name:       req.Name,
-		Picture:    req.Picture,
-	}
-
-	if err := h.userService.CreateUserFromSupabase(user); err != nil {
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+	// Get user from context (provisioned by auth middleware)
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
 		return
 	}
 
@@ -56,26 +40,6 @@ func (h *UserHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// SignIn handles user authentication (not needed for Supabase Auth)
-func (h *UserHandler) SignIn(w http.ResponseWriter, r *http.Request) {
-	// Supabase handles authentication, so this is just a placeholder
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Authentication is handled by Supabase",
-	})
-}
-
-// SignOut handles user sign out (not needed for Supabase Auth)
-func (h *UserHandler) SignOut(w http.ResponseWriter, r *http.Request) {
-	// Supabase handles sign out, so this is just a placeholder
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Sign out is handled by Supabase",
-	})
-}
-
 // GetUser returns the current authenticated user
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (set by auth middleware)
@@ -113,11 +77,12 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update user data
-	user.Name = req.Name
-	user.Picture = req.Picture
+	// Update a copy of the user, since the context's is shared
+	updated := *user
+	updated.Name = req.Name
+	updated.Picture = req.Picture
 
-	if err := h.userService.UpdateUser(user); err != nil {
+	if err := h.userService.UpdateUser(&updated); err != nil {
 		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
@@ -127,7 +92,54 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Profile updated successfully",
-		"data": user,
+		"data": &updated,
+	})
+}
+
+// PatchProfile applies a JSON merge patch to the user's profile, leaving
+// fields the patch doesn't mention unchanged
+func (h *UserHandler) PatchProfile(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	patch, err := readMergePatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	// Only the profile fields are open to clients
+	type profileFields struct {
+		Name    string `json:"name,omitempty"`
+		Picture string `json:"picture,omitempty"`
+	}
+	profile, err := applyMergePatch(&profileFields{Name: user.Name, Picture: user.Picture}, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Update a copy of the user, since the context's is shared
+	updated := *user
+	updated.Name = profile.Name
+	updated.Picture = profile.Picture
+
+	if err := h.userService.UpdateUser(&updated); err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	// Return updated user
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Profile updated successfully",
+		"data":    &updated,
 	})
 }
 
@@ -147,7 +159,13 @@ func (h *UserHandler) GetStyleProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Skip the body if the client's copy is current
+	if notModified(w, r, profile.Version) {
+		return
+	}
+
 	// Return style profile
+	setETag(w, profile.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -174,17 +192,96 @@ func (h *UserHandler) UpdateStyleProfile(w http.ResponseWriter, r *http.Request)
 	// Set user ID
 	profile.UserID = user.ID
 
+	// An If-Match precondition takes priority over the version in the body
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		profile.Version = version
+	}
+
 	// Save style profile
-	if err := h.userService.SaveStyleProfile(&profile); err != nil {
+	err := h.userService.SaveStyleProfile(&profile)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Failed to save style profile", http.StatusInternalServerError)
 		return
 	}
 
 	// Return updated profile
+	setETag(w, profile.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Style profile updated successfully",
 		"data": profile,
 	})
-}
\ No newline at end of file
+}
+
+// PatchStyleProfile applies a JSON merge patch to the user's style profile,
+// starting from an empty profile if the user doesn't have one yet
+func (h *UserHandler) PatchStyleProfile(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	patch, err := readMergePatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	// Get style profile
+	existing, err := h.userService.GetStyleProfile(user.ID)
+	if err != nil {
+		existing = &domain.StyleProfile{UserID: user.ID}
+	}
+
+	// Apply the patch to the current profile
+	profile, err := applyMergePatch(existing, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	profile.ID = existing.ID
+	profile.UserID = user.ID
+
+	// An If-Match precondition takes priority over the version the patch was applied to
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		profile.Version = version
+	}
+
+	// Save style profile
+	err = h.userService.SaveStyleProfile(profile)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save style profile", http.StatusInternalServerError)
+		return
+	}
+
+	// Return updated profile
+	setETag(w, profile.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Style profile updated successfully",
+		"data":    profile,
+	})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// batchItemsRequest is the body of a batch create or update
+type batchItemsRequest struct {
+	Items  []*domain.ClothingItem `json:"items"`
+	Atomic bool                   `json:"atomic"`
+}
+
+// batchDeleteRequest is the body of a batch delete
+type batchDeleteRequest struct {
+	IDs    []string `json:"ids"`
+	Atomic bool     `json:"atomic"`
+}
+
+// BatchAddItems adds several clothing items to the user's wardrobe
+func (h *WardrobeHandler) BatchAddItems(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req batchItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Add items
+	results, err := h.wardrobeService.BatchAddItems(user.ID, req.Items, req.Atomic || atomicParam(r))
+	writeBatchResults(w, results, err)
+}
+
+// BatchUpdateItems updates several of the user's clothing items
+func (h *WardrobeHandler) BatchUpdateItems(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req batchItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update items
+	results, err := h.wardrobeService.BatchUpdateItems(user.ID, req.Items, req.Atomic || atomicParam(r))
+	writeBatchResults(w, results, err)
+}
+
+// BatchDeleteItems deletes several of the user's clothing items
+func (h *WardrobeHandler) BatchDeleteItems(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req batchDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Delete items
+	results, err := h.wardrobeService.BatchDeleteItems(user.ID, req.IDs, req.Atomic || atomicParam(r))
+	writeBatchResults(w, results, err)
+}
+
+// atomicParam reports whether the request asked for an all-or-nothing batch
+// with ?atomic=true
+func atomicParam(r *http.Request) bool {
+	return r.URL.Query().Get("atomic") == "true"
+}
+
+// writeBatchResults responds with the per-entry results of a batch. An
+// aborted atomic batch still returns its results, so the client can see which
+// entry failed.
+func writeBatchResults(w http.ResponseWriter, results []*domain.BatchResult, err error) {
+	status := http.StatusOK
+	message := "Batch applied"
+	switch {
+	case errors.Is(err, domain.ErrBatchAborted):
+		status = http.StatusUnprocessableEntity
+		message = err.Error()
+	case errors.Is(err, domain.ErrTransactionsUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    results,
+	})
+}
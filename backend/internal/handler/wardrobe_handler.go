@@ -2,8 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/lilo/backend/internal/domain"
 )
@@ -30,36 +30,32 @@ func (h *WardrobeHandler) GetItems(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse query parameters for filters
-	filters := make(map[string]interface{})
-
-	if category := r.URL.Query().Get("category"); category != "" {
-		filters["category"] = category
-	}
-	if color := r.URL.Query().Get("color"); color != "" {
-		filters["color"] = color
-	}
-	if season := r.URL.Query().Get("season"); season != "" {
-		filters["season"] = season
+	query, err := parseWardrobeQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if isOwnedStr := r.URL.Query().Get("isOwned"); isOwnedStr != "" {
-		if isOwned, err := strconv.ParseBool(isOwnedStr); err == nil {
-			filters["isOwned"] = isOwned
-		}
+
+	// Parse pagination parameters
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Get items
-	items, err := h.wardrobeService.GetUserItems(user.ID, filters)
+	items, pageInfo, err := h.wardrobeService.GetUserItems(user.ID, query, page)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to get wardrobe items", http.StatusInternalServerError)
 		return
 	}
 
-	// Return items
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data": items,
-	})
+	// Return one page of items
+	writePage(w, r, items, pageInfo)
 }
 
 // AddItem adds a new clothing item to the user's wardrobe
@@ -88,6 +84,7 @@ func (h *WardrobeHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return created item
+	setETag(w, item.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -119,7 +116,13 @@ func (h *WardrobeHandler) GetItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Skip the body if the client's copy is current
+	if notModified(w, r, item.Version) {
+		return
+	}
+
 	// Return item
+	setETag(w, item.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -154,13 +157,105 @@ func (h *WardrobeHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	item.ID = itemID
 	item.UserID = user.ID
 
+	// An If-Match precondition takes priority over the version in the body
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		item.Version = version
+	}
+
+	// Update item
+	err := h.wardrobeService.UpdateItem(&item)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return updated item
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Item updated successfully",
+		"data":    item,
+	})
+}
+
+// PatchItem applies a JSON merge patch to a clothing item
+func (h *WardrobeHandler) PatchItem(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get item ID from URL path
+	itemID := r.PathValue("id")
+	if itemID == "" {
+		http.Error(w, "Item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	patch, err := readMergePatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), patchErrorStatus(err))
+		return
+	}
+
+	// Get item
+	existing, err := h.wardrobeService.GetItem(itemID)
+	if err != nil {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns the item
+	if existing.UserID != user.ID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	// Apply the patch to the current item
+	item, err := applyMergePatch(existing, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	item.ID = existing.ID
+	item.UserID = existing.UserID
+
+	// An If-Match precondition takes priority over the version the patch was applied to
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, domain.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if version != 0 {
+		item.Version = version
+	}
+
 	// Update item
-	if err := h.wardrobeService.UpdateItem(&item); err != nil {
+	err = h.wardrobeService.UpdateItem(item)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Return updated item
+	setETag(w, item.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -211,10 +306,77 @@ func (h *WardrobeHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetCategories returns all available clothing categories
+// ChangeItemStatus moves a clothing item to a new lifecycle state
+func (h *WardrobeHandler) ChangeItemStatus(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get item ID from URL path
+	itemID := r.PathValue("id")
+	if itemID == "" {
+		http.Error(w, "Item ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Status domain.ItemStatus `json:"status"`
+		Note   string            `json:"note"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Verify user owns the item
+	item, err := h.wardrobeService.GetItem(itemID)
+	if err != nil {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+
+	if item.UserID != user.ID {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	// Change status
+	item, err = h.wardrobeService.ChangeItemStatus(itemID, req.Status, req.Note)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrInvalidStatusTransition) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Return updated item
+	setETag(w, item.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Item status updated successfully",
+		"data":    item,
+	})
+}
+
+// GetCategories returns the built-in clothing categories merged with the user's own
 func (h *WardrobeHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
 	// Get categories
-	categories, err := h.wardrobeService.GetCategories()
+	categories, err := h.wardrobeService.GetCategories(user.ID)
 	if err != nil {
 		http.Error(w, "Failed to get categories", http.StatusInternalServerError)
 		return
@@ -227,3 +389,135 @@ func (h *WardrobeHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 		"data": categories,
 	})
 }
+
+// AddCategory creates a personal category for the user
+func (h *WardrobeHandler) AddCategory(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Name          string   `json:"name"`
+		Subcategories []string `json:"subcategories"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Add category
+	category, err := h.wardrobeService.AddCategory(user.ID, req.Name, req.Subcategories)
+	if err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return created category
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Category added successfully",
+		"data":    category,
+	})
+}
+
+// AddSubcategory adds a personal subcategory to a category
+func (h *WardrobeHandler) AddSubcategory(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get category ID from URL path
+	categoryID := r.PathValue("id")
+	if categoryID == "" {
+		http.Error(w, "Category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Add subcategory
+	category, err := h.wardrobeService.AddSubcategory(user.ID, categoryID, req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return updated category
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Subcategory added successfully",
+		"data":    category,
+	})
+}
+
+// RenameCategory renames a personal category and migrates its items
+func (h *WardrobeHandler) RenameCategory(w http.ResponseWriter, r *http.Request) {
+	// Get user from context
+	user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+	if !ok {
+		http.Error(w, "User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// Get category ID from URL path
+	categoryID := r.PathValue("id")
+	if categoryID == "" {
+		http.Error(w, "Category ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body
+	var req struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Rename category
+	category, err := h.wardrobeService.RenameCategory(user.ID, categoryID, req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), categoryErrorStatus(err))
+		return
+	}
+
+	// Return renamed category
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Category renamed successfully",
+		"data":    category,
+	})
+}
+
+// categoryErrorStatus maps taxonomy errors to HTTP status codes
+func categoryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrCategoryExists), errors.Is(err, domain.ErrCategoryInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// WebhookHandler handles events sent by the identity provider
+type WebhookHandler struct {
+	authWebhookService domain.AuthWebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(authWebhookService domain.AuthWebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		authWebhookService: authWebhookService,
+	}
+}
+
+// supabaseAuthUser is the part of a row in Supabase's auth.users table the app uses
+type supabaseAuthUser struct {
+	ID           string                 `json:"id"`
+	Email        string                 `json:"email"`
+	UserMetadata map[string]interface{} `json:"raw_user_meta_data"`
+	AppMetadata  map[string]interface{} `json:"raw_app_meta_data"`
+	DeletedAt    *string                `json:"deleted_at"`
+}
+
+// supabaseAuthEvent is the payload of a database webhook on auth.users
+type supabaseAuthEvent struct {
+	Type      string            `json:"type"`
+	Table     string            `json:"table"`
+	Schema    string            `json:"schema"`
+	Record    *supabaseAuthUser `json:"record"`
+	OldRecord *supabaseAuthUser `json:"old_record"`
+}
+
+// SupabaseAuth applies a user created, updated or deleted in Supabase. The
+// request's signature has already been checked by the webhook middleware.
+func (h *WebhookHandler) SupabaseAuth(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var payload supabaseAuthEvent
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if (payload.Schema != "" && payload.Schema != "auth") || (payload.Table != "" && payload.Table != "users") {
+		http.Error(w, "Only auth.users events are accepted", http.StatusBadRequest)
+		return
+	}
+
+	// Deletions only carry the old row. Supabase soft-deletes users by
+	// setting deleted_at, which is a deletion as far as the app is concerned.
+	eventType := payload.Type
+	record := payload.Record
+	switch eventType {
+	case domain.AuthEventUserCreated, domain.AuthEventUserUpdated:
+		if record != nil && record.DeletedAt != nil {
+			eventType = domain.AuthEventUserDeleted
+		}
+	case domain.AuthEventUserDeleted:
+		record = payload.OldRecord
+	default:
+		http.Error(w, "Unsupported event type", http.StatusBadRequest)
+		return
+	}
+	if record == nil || record.ID == "" {
+		http.Error(w, "Event has no user record", http.StatusBadRequest)
+		return
+	}
+
+	// Apply event
+	event := &domain.AuthEvent{
+		Type: eventType,
+		Identity: &domain.User{
+			SupabaseID: record.ID,
+			Email:      record.Email,
+			Name:       stringFromMap(record.UserMetadata, "name"),
+			Picture:    stringFromMap(record.UserMetadata, "avatar_url"),
+			Role:       domain.RoleFromMetadata(record.AppMetadata),
+		},
+	}
+	err := h.authWebhookService.HandleAuthEvent(event)
+	if errors.Is(err, domain.ErrEmailTaken) {
+		http.Error(w, "An account with this email already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to apply auth event", http.StatusInternalServerError)
+		return
+	}
+
+	// Return success response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Event applied",
+	})
+}
+
+// stringFromMap returns the string stored under key, or "" if there isn't one
+func stringFromMap(m map[string]interface{}, key string) string {
+	if value, ok := m[key].(string); ok {
+		return value
+	}
+	return ""
+}
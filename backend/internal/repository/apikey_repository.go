@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lilo/backend/internal/domain"
+)
+
+// InMemoryAPIKeyRepository implements APIKeyRepository using in-memory storage
+type InMemoryAPIKeyRepository struct {
+	keys   map[string]*domain.APIKey // ID -> key
+	byHash map[string]string         // secret hash -> ID
+	mu     sync.RWMutex
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository() domain.APIKeyRepository {
+	return &InMemoryAPIKeyRepository{
+		keys:   make(map[string]*domain.APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+// Create stores a new key
+func (r *InMemoryAPIKeyRepository) Create(key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	copied := *key
+	r.keys[key.ID] = &copied
+	r.byHash[key.Hash] = key.ID
+	return nil
+}
+
+// GetByHash retrieves a key by the hash of its secret
+func (r *InMemoryAPIKeyRepository) GetByHash(hash string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.byHash[hash]
+	if !exists {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	copied := *r.keys[id]
+	return &copied, nil
+}
+
+// ListByUserID retrieves a user's keys, newest first
+func (r *InMemoryAPIKeyRepository) ListByUserID(userID string) ([]*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []*domain.APIKey{}
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			copied := *key
+			keys = append(keys, &copied)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// Update replaces an existing key
+func (r *InMemoryAPIKeyRepository) Update(key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key.ID]; !exists {
+		return domain.ErrAPIKeyNotFound
+	}
+	copied := *key
+	r.keys[key.ID] = &copied
+	return nil
+}
+
+// RecordUse sets when a key was last used, unless it has been revoked
+func (r *InMemoryAPIKeyRepository) RecordUse(id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return domain.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	copied := *key
+	copied.LastUsedAt = &usedAt
+	r.keys[id] = &copied
+	return nil
+}
+
+// DeleteByUserID removes all of a user's keys
+func (r *InMemoryAPIKeyRepository) DeleteByUserID(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, key := range r.keys {
+		if key.UserID == userID {
+			delete(r.byHash, key.Hash)
+			delete(r.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestAPIKeyRepositoryRecordUseKeepsRevocation(t *testing.T) {
+	const uses = 50
+	now := time.Now()
+
+	repo := NewAPIKeyRepository()
+	key := &domain.APIKey{UserID: "user-1", Name: "cli", Hash: "hash"}
+	if err := repo.Create(key); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < uses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.RecordUse(key.ID, time.Now()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	revoked := *key
+	revoked.RevokedAt = &now
+	if err := repo.Update(&revoked); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	wg.Wait()
+
+	stored, err := repo.GetByHash("hash")
+	if err != nil {
+		t.Fatalf("GetByHash: %v", err)
+	}
+	if stored.RevokedAt == nil {
+		t.Error("revocation was overwritten by recording a use")
+	}
+}
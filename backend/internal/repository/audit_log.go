@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lilo/backend/internal/domain"
+)
+
+// InMemoryAuditLog implements AuditLog using in-memory storage
+type InMemoryAuditLog struct {
+	entries []*domain.AuditEntry
+	mu      sync.RWMutex
+}
+
+// NewAuditLog creates a new audit log
+func NewAuditLog() domain.AuditLog {
+	return &InMemoryAuditLog{}
+}
+
+// Append adds an entry to the end of the log
+func (l *InMemoryAuditLog) Append(entry *domain.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+
+	copied := *entry
+	l.entries = append(l.entries, &copied)
+	return nil
+}
+
+// ListByUserID returns a user's entries, oldest first
+func (l *InMemoryAuditLog) ListByUserID(userID string) ([]*domain.AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := []*domain.AuditEntry{}
+	for _, entry := range l.entries {
+		if entry.UserID == userID {
+			copied := *entry
+			entries = append(entries, &copied)
+		}
+	}
+	return entries, nil
+}
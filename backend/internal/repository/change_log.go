@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// InMemoryChangeLog implements ChangeLog. Only the latest change to each
+// record is kept, so the log grows with the number of records rather than
+// the number of writes, and tombstones are kept for as long as the process runs.
+type InMemoryChangeLog struct {
+	changes map[string]map[string]*domain.Change // userID -> type:id -> latest change
+	seq     int64
+	mu      sync.RWMutex
+}
+
+// NewChangeLog creates a new change log
+func NewChangeLog() domain.ChangeLog {
+	return &InMemoryChangeLog{
+		changes: make(map[string]map[string]*domain.Change),
+	}
+}
+
+// Record notes that a record was written or deleted
+func (l *InMemoryChangeLog) Record(userID, recordType, id string, deleted bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	if _, exists := l.changes[userID]; !exists {
+		l.changes[userID] = make(map[string]*domain.Change)
+	}
+	l.changes[userID][recordType+":"+id] = &domain.Change{
+		Seq:       l.seq,
+		UserID:    userID,
+		Type:      recordType,
+		ID:        id,
+		Deleted:   deleted,
+		ChangedAt: time.Now(),
+	}
+}
+
+// Since returns up to limit of the user's changes after seq, oldest first
+func (l *InMemoryChangeLog) Since(userID string, seq int64, limit int) ([]*domain.Change, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var changes []*domain.Change
+	for _, change := range l.changes[userID] {
+		if change.Seq > seq {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Seq < changes[j].Seq
+	})
+
+	if limit > 0 && len(changes) > limit {
+		return changes[:limit], true, nil
+	}
+	return changes, false, nil
+}
+
+// Purge forgets all of a user's changes, tombstones included
+func (l *InMemoryChangeLog) Purge(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.changes, userID)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"testing"
+)
+
+func TestChangeLogSince(t *testing.T) {
+	log := NewChangeLog()
+	log.Record("user-1", "item", "shirt", false)
+	log.Record("user-1", "item", "scarf", false)
+	log.Record("user-2", "item", "coat", false)
+	log.Record("user-1", "item", "shirt", true)
+
+	tests := []struct {
+		name        string
+		userID      string
+		since       int64
+		limit       int
+		wantIDs     []string
+		wantDeleted []bool
+		wantMore    bool
+	}{
+		{name: "only the latest change to a record", userID: "user-1", wantIDs: []string{"scarf", "shirt"}, wantDeleted: []bool{false, true}},
+		{name: "after a sequence number", userID: "user-1", since: 2, wantIDs: []string{"shirt"}, wantDeleted: []bool{true}},
+		{name: "limited", userID: "user-1", limit: 1, wantIDs: []string{"scarf"}, wantDeleted: []bool{false}, wantMore: true},
+		{name: "another user's changes", userID: "user-2", wantIDs: []string{"coat"}, wantDeleted: []bool{false}},
+		{name: "unknown user", userID: "user-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, more, err := log.Since(tt.userID, tt.since, tt.limit)
+			if err != nil {
+				t.Fatalf("Since: %v", err)
+			}
+			if more != tt.wantMore {
+				t.Errorf("more = %v, want %v", more, tt.wantMore)
+			}
+			if len(changes) != len(tt.wantIDs) {
+				t.Fatalf("changes = %d, want %d", len(changes), len(tt.wantIDs))
+			}
+			for i, change := range changes {
+				if change.ID != tt.wantIDs[i] || change.Deleted != tt.wantDeleted[i] {
+					t.Errorf("change %d = %s deleted=%v, want %s deleted=%v", i, change.ID, change.Deleted, tt.wantIDs[i], tt.wantDeleted[i])
+				}
+				if i > 0 && change.Seq <= changes[i-1].Seq {
+					t.Errorf("change %d is out of order", i)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeLogPurge(t *testing.T) {
+	log := NewChangeLog()
+	log.Record("user-1", "item", "shirt", true)
+	log.Purge("user-1")
+
+	changes, _, err := log.Since("user-1", 0, 0)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes after purge = %d, want 0", len(changes))
+	}
+}
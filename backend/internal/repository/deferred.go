@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/lilo/backend/internal/domain"
+)
+
+// deferredIndex holds back index updates made inside a transaction until it
+// commits, so rolled back writes never reach search results
+type deferredIndex struct {
+	domain.SearchIndex
+	ops []func()
+}
+
+func (d *deferredIndex) IndexItem(item *domain.ClothingItem) {
+	d.ops = append(d.ops, func() { d.SearchIndex.IndexItem(item) })
+}
+
+func (d *deferredIndex) IndexOutfit(outfit *domain.Outfit) {
+	d.ops = append(d.ops, func() { d.SearchIndex.IndexOutfit(outfit) })
+}
+
+func (d *deferredIndex) Remove(docType, id string) {
+	d.ops = append(d.ops, func() { d.SearchIndex.Remove(docType, id) })
+}
+
+func (d *deferredIndex) flush() {
+	for _, op := range d.ops {
+		op()
+	}
+}
+
+// deferredChangeLog holds back change records made inside a transaction until
+// it commits, so clients never sync writes that were rolled back
+type deferredChangeLog struct {
+	domain.ChangeLog
+	ops []func()
+}
+
+func (d *deferredChangeLog) Record(userID, recordType, id string, deleted bool) {
+	d.ops = append(d.ops, func() { d.ChangeLog.Record(userID, recordType, id, deleted) })
+}
+
+func (d *deferredChangeLog) flush() {
+	for _, op := range d.ops {
+		op()
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// InMemoryDeletionRepository implements DeletionRepository using in-memory storage
+type InMemoryDeletionRepository struct {
+	deletions map[string]*domain.AccountDeletion // userID -> latest request
+	mu        sync.RWMutex
+}
+
+// NewDeletionRepository creates a new account deletion repository
+func NewDeletionRepository() domain.DeletionRepository {
+	return &InMemoryDeletionRepository{
+		deletions: make(map[string]*domain.AccountDeletion),
+	}
+}
+
+// Save stores a user's deletion request, replacing any earlier one
+func (r *InMemoryDeletionRepository) Save(deletion *domain.AccountDeletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *deletion
+	r.deletions[deletion.UserID] = &copied
+	return nil
+}
+
+// GetByUserID retrieves a user's latest deletion request
+func (r *InMemoryDeletionRepository) GetByUserID(userID string) (*domain.AccountDeletion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deletion, exists := r.deletions[userID]
+	if !exists {
+		return nil, domain.ErrDeletionNotFound
+	}
+	copied := *deletion
+	return &copied, nil
+}
+
+// ListDue returns pending deletions whose grace period has passed
+func (r *InMemoryDeletionRepository) ListDue(now time.Time) ([]*domain.AccountDeletion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var due []*domain.AccountDeletion
+	for _, deletion := range r.deletions {
+		if deletion.Status == domain.DeletionStatusPending && !deletion.ScheduledFor.After(now) {
+			copied := *deletion
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
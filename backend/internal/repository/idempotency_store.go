@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// sweepInterval is how often expired records are purged
+const sweepInterval = time.Minute
+
+// InMemoryIdempotencyStore implements IdempotencyStore
+type InMemoryIdempotencyStore struct {
+	records   map[string]*domain.IdempotencyRecord // userID:key -> record
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewIdempotencyStore creates a new idempotency store
+func NewIdempotencyStore() domain.IdempotencyStore {
+	return &InMemoryIdempotencyStore{
+		records: make(map[string]*domain.IdempotencyRecord),
+	}
+}
+
+// Reserve claims a key for a new request unless a live record already holds it
+func (s *InMemoryIdempotencyStore) Reserve(userID, key, fingerprint string, ttl time.Duration) (*domain.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	id := userID + ":" + key
+	if record, exists := s.records[id]; exists && now.Before(record.ExpiresAt) {
+		copied := *record
+		return &copied, false, nil
+	}
+
+	s.records[id] = &domain.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Pending:     true,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+// Complete stores the response for a reserved key
+func (s *InMemoryIdempotencyStore) Complete(userID, key string, statusCode int, header map[string][]string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[userID+":"+key]
+	if !exists {
+		return nil
+	}
+	record.Pending = false
+	record.StatusCode = statusCode
+	record.Header = header
+	record.Body = body
+	return nil
+}
+
+// Release frees a reserved key
+func (s *InMemoryIdempotencyStore) Release(userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, userID+":"+key)
+	return nil
+}
+
+// Purge drops all of a user's records
+func (s *InMemoryIdempotencyStore) Purge(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, record := range s.records {
+		if record.UserID == userID {
+			delete(s.records, id)
+		}
+	}
+	return nil
+}
+
+// sweep purges expired records; must be called with the lock held
+func (s *InMemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for id, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, id)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStoreConcurrentReserve(t *testing.T) {
+	const requests = 50
+
+	tests := []struct {
+		name string
+		// userID picks the user making request i
+		userID       func(i int) string
+		wantReserved int
+	}{
+		{name: "one request holds a key", userID: func(i int) string { return "user-1" }, wantReserved: 1},
+		{name: "keys are per user", userID: func(i int) string { return []string{"user-1", "user-2"}[i%2] }, wantReserved: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewIdempotencyStore()
+
+			var (
+				wg       sync.WaitGroup
+				reserved atomic.Int64
+			)
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, ok, err := store.Reserve(tt.userID(i), "key", "fingerprint", time.Hour)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						reserved.Add(1)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if got := int(reserved.Load()); got != tt.wantReserved {
+				t.Errorf("reserved = %d, want %d", got, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStoreReleaseAllowsRetry(t *testing.T) {
+	store := NewIdempotencyStore()
+	if _, ok, err := store.Reserve("user-1", "key", "fingerprint", time.Hour); err != nil || !ok {
+		t.Fatalf("Reserve() = %v, %v, want a reservation", ok, err)
+	}
+	if err := store.Release("user-1", "key"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok, err := store.Reserve("user-1", "key", "fingerprint", time.Hour); err != nil || !ok {
+		t.Errorf("Reserve() after release = %v, %v, want a reservation", ok, err)
+	}
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/lilo/backend/internal/domain"
+)
+
+// s3RequestTimeout bounds each call to S3
+const s3RequestTimeout = 30 * time.Second
+
+// signedURL is a cached signed URL and when it stops being handed out
+type signedURL struct {
+	url       string
+	reuseTill time.Time
+}
+
+// S3ImageStore implements ImageStore over the app's private S3 buckets.
+// Images are identified by their unsigned URL, in either virtual-hosted or
+// path style, and each user's images are kept under keys starting
+// "<user ID>/". Signed URLs are cached and handed out again for the first
+// half of their life, so each one given to a client is valid for at least
+// half the TTL and list responses don't sign every image on every request.
+type S3ImageStore struct {
+	client    *s3.Client
+	presigner *s3.PresignClient
+	buckets   map[string]bool
+	ttl       time.Duration
+
+	signed    map[string]signedURL // bucket/key -> signed URL
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewS3ImageStore creates an image store for the given buckets, signing URLs
+// that are valid for ttl
+func NewS3ImageStore(client *s3.Client, buckets []string, ttl time.Duration) domain.ImageStore {
+	store := &S3ImageStore{
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+		buckets:   make(map[string]bool, len(buckets)),
+		ttl:       ttl,
+		signed:    make(map[string]signedURL),
+	}
+	for _, bucket := range buckets {
+		store.buckets[bucket] = true
+	}
+	return store
+}
+
+// Fetch downloads one of the owner's stored images
+func (s *S3ImageStore) Fetch(ownerID, rawURL string) ([]byte, string, error) {
+	bucket, key, err := s.locateOwned(ownerID, rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get %s/%s: %w", bucket, key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s/%s: %w", bucket, key, err)
+	}
+	return data, aws.ToString(out.ContentType), nil
+}
+
+// Delete removes one of the owner's stored images. Deleting one that's
+// already gone succeeds.
+func (s *S3ImageStore) Delete(ownerID, rawURL string) error {
+	bucket, key, err := s.locateOwned(ownerID, rawURL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// SignURL returns a presigned GET URL for one of the owner's stored images
+func (s *S3ImageStore) SignURL(ownerID, rawURL string) (string, error) {
+	bucket, key, err := s.locateOwned(ownerID, rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	id := bucket + "/" + key
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.signed[id]
+	s.mu.Unlock()
+	if ok && now.Before(cached.reuseTill) {
+		return cached.url, nil
+	}
+
+	// Presigning is done locally, without calling S3
+	req, err := s.presigner.PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(s.ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", id, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	s.signed[id] = signedURL{url: req.URL, reuseTill: now.Add(s.ttl / 2)}
+	return req.URL, nil
+}
+
+// sweep drops signed URLs that are no longer handed out; must be called with
+// the lock held
+func (s *S3ImageStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for id, cached := range s.signed {
+		if !now.Before(cached.reuseTill) {
+			delete(s.signed, id)
+		}
+	}
+}
+
+// locate finds the bucket and key an image URL points to, if it's one of ours
+func (s *S3ImageStore) locate(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return "", "", domain.ErrImageNotStored
+	}
+
+	var bucket, key string
+	host, path := u.Hostname(), strings.TrimPrefix(u.Path, "/")
+	if i := strings.Index(host, ".s3"); i > 0 {
+		// Virtual-hosted style: bucket.s3.region.amazonaws.com/key
+		bucket, key = host[:i], path
+	} else if strings.HasPrefix(host, "s3") {
+		// Path style: s3.region.amazonaws.com/bucket/key
+		bucket, key, _ = strings.Cut(path, "/")
+	}
+	if !s.buckets[bucket] || key == "" {
+		return "", "", domain.ErrImageNotStored
+	}
+	return bucket, key, nil
+}
+
+// locateOwned locates an image, checking that it's under the owner's prefix
+func (s *S3ImageStore) locateOwned(ownerID, rawURL string) (string, string, error) {
+	bucket, key, err := s.locate(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	if ownerID == "" || !strings.HasPrefix(key, ownerID+"/") {
+		return "", "", domain.ErrImageNotOwned
+	}
+	return bucket, key, nil
+}
+
+// NoImageStore implements ImageStore for deployments without image storage,
+// where every image URL is external
+type NoImageStore struct{}
+
+// NewNoImageStore creates an image store that holds no images
+func NewNoImageStore() domain.ImageStore {
+	return NoImageStore{}
+}
+
+// Fetch reports that the image isn't stored here
+func (NoImageStore) Fetch(string, string) ([]byte, string, error) {
+	return nil, "", domain.ErrImageNotStored
+}
+
+// Delete reports that the image isn't stored here
+func (NoImageStore) Delete(string, string) error {
+	return domain.ErrImageNotStored
+}
+
+// SignURL reports that the image isn't stored here
+func (NoImageStore) SignURL(string, string) (string, error) {
+	return "", domain.ErrImageNotStored
+}
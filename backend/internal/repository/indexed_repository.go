@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"github.com/lilo/backend/internal/domain"
+)
+
+// IndexedWardrobeRepository wraps a WardrobeRepository and keeps the search index
+// in step with every item write
+type IndexedWardrobeRepository struct {
+	domain.WardrobeRepository
+	index domain.SearchIndex
+}
+
+// NewIndexedWardrobeRepository creates a wardrobe repository that updates index on writes
+func NewIndexedWardrobeRepository(repo domain.WardrobeRepository, index domain.SearchIndex) domain.WardrobeRepository {
+	return &IndexedWardrobeRepository{
+		WardrobeRepository: repo,
+		index:              index,
+	}
+}
+
+// CreateItem creates a new clothing item and indexes it
+func (r *IndexedWardrobeRepository) CreateItem(item *domain.ClothingItem) error {
+	if err := r.WardrobeRepository.CreateItem(item); err != nil {
+		return err
+	}
+	r.index.IndexItem(item)
+	return nil
+}
+
+// UpdateItem updates an existing clothing item and re-indexes it
+func (r *IndexedWardrobeRepository) UpdateItem(item *domain.ClothingItem) error {
+	if err := r.WardrobeRepository.UpdateItem(item); err != nil {
+		return err
+	}
+	r.index.IndexItem(item)
+	return nil
+}
+
+// DeleteItem deletes a clothing item and removes it from the index
+func (r *IndexedWardrobeRepository) DeleteItem(id string) error {
+	if err := r.WardrobeRepository.DeleteItem(id); err != nil {
+		return err
+	}
+	r.index.Remove(domain.SearchTypeItem, id)
+	return nil
+}
+
+// WithinTransaction runs fn in a transaction of the wrapped repository and
+// indexes its writes once it commits
+func (r *IndexedWardrobeRepository) WithinTransaction(fn func(tx domain.WardrobeRepository) error) error {
+	transactor, ok := r.WardrobeRepository.(domain.WardrobeTransactor)
+	if !ok {
+		return domain.ErrTransactionsUnsupported
+	}
+
+	index := &deferredIndex{SearchIndex: r.index}
+	err := transactor.WithinTransaction(func(tx domain.WardrobeRepository) error {
+		return fn(&IndexedWardrobeRepository{WardrobeRepository: tx, index: index})
+	})
+	if err == nil {
+		index.flush()
+	}
+	return err
+}
+
+// IndexedOutfitRepository wraps an OutfitRepository and keeps the search index
+// in step with every outfit write
+type IndexedOutfitRepository struct {
+	domain.OutfitRepository
+	index domain.SearchIndex
+}
+
+// NewIndexedOutfitRepository creates an outfit repository that updates index on writes
+func NewIndexedOutfitRepository(repo domain.OutfitRepository, index domain.SearchIndex) domain.OutfitRepository {
+	return &IndexedOutfitRepository{
+		OutfitRepository: repo,
+		index:            index,
+	}
+}
+
+// CreateOutfit creates a new outfit and indexes it
+func (r *IndexedOutfitRepository) CreateOutfit(outfit *domain.Outfit) error {
+	if err := r.OutfitRepository.CreateOutfit(outfit); err != nil {
+		return err
+	}
+	r.index.IndexOutfit(outfit)
+	return nil
+}
+
+// UpdateOutfit updates an existing outfit and re-indexes it
+func (r *IndexedOutfitRepository) UpdateOutfit(outfit *domain.Outfit) error {
+	if err := r.OutfitRepository.UpdateOutfit(outfit); err != nil {
+		return err
+	}
+	r.index.IndexOutfit(outfit)
+	return nil
+}
+
+// DeleteOutfit deletes an outfit and removes it from the index
+func (r *IndexedOutfitRepository) DeleteOutfit(id string) error {
+	if err := r.OutfitRepository.DeleteOutfit(id); err != nil {
+		return err
+	}
+	r.index.Remove(domain.SearchTypeOutfit, id)
+	return nil
+}
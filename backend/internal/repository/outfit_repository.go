@@ -31,6 +31,7 @@ func (r *InMemoryOutfitRepository) CreateOutfit(outfit *domain.Outfit) error {
 	if outfit.ID == "" {
 		outfit.ID = uuid.New().String()
 	}
+	outfit.Version = 1
 	outfit.CreatedAt = time.Now()
 	outfit.UpdatedAt = time.Now()
 
@@ -50,8 +51,8 @@ func (r *InMemoryOutfitRepository) GetOutfitByID(id string) (*domain.Outfit, err
 	return outfit, nil
 }
 
-// GetOutfitsByUserID retrieves all outfits for a user with optional filters
-func (r *InMemoryOutfitRepository) GetOutfitsByUserID(userID string, filters map[string]interface{}) ([]*domain.Outfit, error) {
+// GetOutfitsByUserID retrieves all outfits for a user matching a query
+func (r *InMemoryOutfitRepository) GetOutfitsByUserID(userID string, query domain.OutfitQuery) ([]*domain.Outfit, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -59,7 +60,7 @@ func (r *InMemoryOutfitRepository) GetOutfitsByUserID(userID string, filters map
 	for _, outfit := range r.outfits {
 		if outfit.UserID == userID {
 			// Apply filters if provided
-			if r.matchesFilters(outfit, filters) {
+			if r.matchesFilters(outfit, query) {
 				outfits = append(outfits, outfit)
 			}
 		}
@@ -67,53 +68,29 @@ func (r *InMemoryOutfitRepository) GetOutfitsByUserID(userID string, filters map
 	return outfits, nil
 }
 
-// matchesFilters checks if an outfit matches the provided filters
-func (r *InMemoryOutfitRepository) matchesFilters(outfit *domain.Outfit, filters map[string]interface{}) bool {
-	if filters == nil {
-		return true
+// ListOutfitsByUserID retrieves one sorted page of a user's outfits matching a query
+func (r *InMemoryOutfitRepository) ListOutfitsByUserID(userID string, query domain.OutfitQuery, page domain.PageRequest) ([]*domain.Outfit, *domain.PageInfo, error) {
+	outfits, err := r.GetOutfitsByUserID(userID, query)
+	if err != nil {
+		return nil, nil, err
 	}
+	return paginate(outfits, outfitSortKey, page)
+}
 
-	if isFavorite, ok := filters["isFavorite"]; ok {
-		if outfit.IsFavorite != isFavorite.(bool) {
-			return false
-		}
+// matchesFilters checks if an outfit matches the provided query
+func (r *InMemoryOutfitRepository) matchesFilters(outfit *domain.Outfit, query domain.OutfitQuery) bool {
+	if query.IsFavorite != nil && outfit.IsFavorite != *query.IsFavorite {
+		return false
 	}
-
-	if isRecommended, ok := filters["isRecommended"]; ok {
-		if outfit.IsRecommended != isRecommended.(bool) {
-			return false
-		}
+	if query.IsRecommended != nil && outfit.IsRecommended != *query.IsRecommended {
+		return false
 	}
 
-	if occasion, ok := filters["occasion"]; ok {
-		occasionStr := occasion.(string)
-		found := false
-		for _, o := range outfit.Occasion {
-			if o == occasionStr {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	if season, ok := filters["season"]; ok {
-		seasonStr := season.(string)
-		found := false
-		for _, s := range outfit.Season {
-			if s == seasonStr {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	return true
+	return query.Occasion.MatchesAny(outfit.Occasion) &&
+		query.Season.MatchesAny(outfit.Season) &&
+		query.Item.MatchesAny(outfit.Items) &&
+		query.Created.Matches(&outfit.CreatedAt) &&
+		query.LastWorn.Matches(outfit.LastWornAt)
 }
 
 // UpdateOutfit updates an existing outfit
@@ -121,10 +98,15 @@ func (r *InMemoryOutfitRepository) UpdateOutfit(outfit *domain.Outfit) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.outfits[outfit.ID]; !exists {
+	existing, exists := r.outfits[outfit.ID]
+	if !exists {
 		return domain.ErrUserNotFound // Reusing error for simplicity
 	}
+	if outfit.Version != existing.Version {
+		return domain.ErrVersionConflict
+	}
 
+	outfit.Version++
 	outfit.UpdatedAt = time.Now()
 	r.outfits[outfit.ID] = outfit
 	return nil
@@ -154,12 +136,14 @@ func (r *InMemoryOutfitRepository) SetFavorite(id string, favorite bool) error {
 	}
 
 	outfit.IsFavorite = favorite
+	outfit.Version++
 	outfit.UpdatedAt = time.Now()
 	r.outfits[id] = outfit
 	return nil
 }
 
-// CreateReflection creates a new reflection
+// CreateReflection creates a new reflection and counts it as a wear of its
+// outfit. Reflections dated in the past don't move the last worn time back.
 func (r *InMemoryOutfitRepository) CreateReflection(reflection *domain.Reflection) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -170,6 +154,21 @@ func (r *InMemoryOutfitRepository) CreateReflection(reflection *domain.Reflectio
 	reflection.CreatedAt = time.Now()
 
 	r.reflections[reflection.ID] = reflection
+
+	if outfit, exists := r.outfits[reflection.OutfitID]; exists {
+		wornAt := reflection.Date
+		if wornAt.IsZero() {
+			wornAt = reflection.CreatedAt
+		}
+		worn := *outfit
+		worn.WearCount++
+		if worn.LastWornAt == nil || wornAt.After(*worn.LastWornAt) {
+			worn.LastWornAt = &wornAt
+		}
+		worn.Version++
+		worn.UpdatedAt = reflection.CreatedAt
+		r.outfits[worn.ID] = &worn
+	}
 	return nil
 }
 
@@ -186,3 +185,16 @@ func (r *InMemoryOutfitRepository) GetReflectionsByUserID(userID string) ([]*dom
 	}
 	return reflections, nil
 }
+
+// DeleteReflectionsByUserID deletes all reflections of a user
+func (r *InMemoryOutfitRepository) DeleteReflectionsByUserID(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, reflection := range r.reflections {
+		if reflection.UserID == userID {
+			delete(r.reflections, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestOutfitRepositoryConcurrentReflections(t *testing.T) {
+	const writers = 50
+	latest := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := NewOutfitRepository()
+	outfit := &domain.Outfit{UserID: "user-1", Name: "Office", Items: []string{"item-1"}}
+	if err := repo.CreateOutfit(outfit); err != nil {
+		t.Fatalf("CreateOutfit: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reflection := &domain.Reflection{
+				UserID:     "user-1",
+				OutfitID:   outfit.ID,
+				Date:       latest.AddDate(0, 0, -i),
+				Confidence: 4,
+				Comfort:    4,
+			}
+			if err := repo.CreateReflection(reflection); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := repo.GetOutfitByID(outfit.ID)
+	if err != nil {
+		t.Fatalf("GetOutfitByID: %v", err)
+	}
+	if stored.WearCount != writers {
+		t.Errorf("wear count = %d, want %d", stored.WearCount, writers)
+	}
+	if stored.LastWornAt == nil || !stored.LastWornAt.Equal(latest) {
+		t.Errorf("last worn at = %v, want %v", stored.LastWornAt, latest)
+	}
+	reflections, err := repo.GetReflectionsByUserID("user-1")
+	if err != nil {
+		t.Fatalf("GetReflectionsByUserID: %v", err)
+	}
+	if len(reflections) != writers {
+		t.Errorf("reflections = %d, want %d", len(reflections), writers)
+	}
+}
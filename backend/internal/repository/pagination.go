@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// sortKey holds the value a record is ordered by, with its ID as a tie-breaker
+// so that ordering is total and stable across pages
+type sortKey struct {
+	Time  time.Time `json:"t,omitempty"`
+	Text  string    `json:"s,omitempty"`
+	Count int       `json:"n,omitempty"`
+	ID    string    `json:"id"`
+}
+
+// cursor is the decoded form of the opaque page cursor used by the in-memory repositories
+type cursor struct {
+	SortBy     domain.SortField `json:"sort"`
+	Descending bool             `json:"desc"`
+	After      sortKey          `json:"after"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// compareKeys orders two sort keys by the given field, then by ID
+func compareKeys(a, b sortKey, field domain.SortField) int {
+	var c int
+	switch field {
+	case domain.SortByName:
+		c = strings.Compare(a.Text, b.Text)
+	case domain.SortByWearCount:
+		c = a.Count - b.Count
+	default:
+		c = a.Time.Compare(b.Time)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// paginate sorts records and returns the page that follows the request's cursor
+func paginate[T any](records []T, keyOf func(T, domain.SortField) sortKey, page domain.PageRequest) ([]T, *domain.PageInfo, error) {
+	if page.SortBy == "" {
+		page.SortBy = domain.SortByCreatedAt
+	}
+	if page.Limit <= 0 {
+		page.Limit = domain.DefaultPageLimit
+	}
+
+	less := func(a, b sortKey) bool {
+		c := compareKeys(a, b, page.SortBy)
+		if page.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+
+	keys := make(map[int]sortKey, len(records))
+	for i, r := range records {
+		keys[i] = keyOf(r, page.SortBy)
+	}
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return less(keys[order[i]], keys[order[j]])
+	})
+
+	start := 0
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		// A cursor only makes sense for the ordering it was issued for
+		if c.SortBy != page.SortBy || c.Descending != page.Descending {
+			return nil, nil, domain.ErrInvalidCursor
+		}
+		start = sort.Search(len(order), func(i int) bool {
+			return less(c.After, keys[order[i]])
+		})
+	}
+
+	end := start + page.Limit
+	if end > len(order) {
+		end = len(order)
+	}
+
+	result := make([]T, 0, end-start)
+	for _, i := range order[start:end] {
+		result = append(result, records[i])
+	}
+
+	info := &domain.PageInfo{
+		HasMore: end < len(order),
+		Limit:   page.Limit,
+	}
+	if info.HasMore {
+		info.NextCursor = encodeCursor(cursor{
+			SortBy:     page.SortBy,
+			Descending: page.Descending,
+			After:      keys[order[end-1]],
+		})
+	}
+	return result, info, nil
+}
+
+func itemSortKey(item *domain.ClothingItem, field domain.SortField) sortKey {
+	key := sortKey{ID: item.ID}
+	switch field {
+	case domain.SortByName:
+		key.Text = strings.ToLower(item.Name)
+	case domain.SortByWearCount:
+		key.Count = item.WearCount
+	case domain.SortByUpdatedAt:
+		key.Time = item.UpdatedAt
+	default:
+		key.Time = item.CreatedAt
+	}
+	return key
+}
+
+func outfitSortKey(outfit *domain.Outfit, field domain.SortField) sortKey {
+	key := sortKey{ID: outfit.ID}
+	switch field {
+	case domain.SortByName:
+		key.Text = strings.ToLower(outfit.Name)
+	case domain.SortByWearCount:
+		key.Count = outfit.WearCount
+	case domain.SortByUpdatedAt:
+		key.Time = outfit.UpdatedAt
+	default:
+		key.Time = outfit.CreatedAt
+	}
+	return key
+}
+
+func userSortKey(user *domain.User, field domain.SortField) sortKey {
+	key := sortKey{ID: user.ID}
+	switch field {
+	case domain.SortByName:
+		key.Text = strings.ToLower(user.Name)
+	case domain.SortByUpdatedAt:
+		key.Time = user.UpdatedAt
+	default:
+		key.Time = user.CreatedAt
+	}
+	return key
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// tokenBucket is a bucket's level as of the last request
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// InMemoryRateLimitStore implements RateLimitStore for a single instance
+type InMemoryRateLimitStore struct {
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewRateLimitStore creates a new in-memory rate limit store
+func NewRateLimitStore() domain.RateLimitStore {
+	return &InMemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Take refills the bucket for the time since its last request, then removes a token
+func (s *InMemoryRateLimitStore) Take(key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	perToken := limit.Window / time.Duration(limit.Requests)
+
+	bucket, exists := s.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = bucket
+	}
+	elapsed := now.Sub(bucket.updatedAt)
+	bucket.tokens = math.Min(capacity, bucket.tokens+float64(elapsed)/float64(perToken))
+	bucket.updatedAt = now
+
+	result := &domain.RateLimitResult{Limit: limit.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+	result.Remaining = int(bucket.tokens)
+	result.ResetAfter = time.Duration((capacity - bucket.tokens) * float64(perToken))
+	bucket.fullAt = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// sweep drops buckets that have refilled, since a new bucket starts full.
+// Callers must hold the lock.
+func (s *InMemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestRateLimitStoreConcurrentTake(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    domain.RateLimit
+		requests int
+	}{
+		{name: "fewer requests than the limit", limit: domain.RateLimit{Requests: 100, Window: time.Hour}, requests: 50},
+		{name: "more requests than the limit", limit: domain.RateLimit{Requests: 10, Window: time.Hour}, requests: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewRateLimitStore()
+
+			var (
+				wg      sync.WaitGroup
+				allowed atomic.Int64
+			)
+			for i := 0; i < tt.requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, err := store.Take("key", tt.limit)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if result.Allowed {
+						allowed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			want := min(tt.requests, tt.limit.Requests)
+			if got := int(allowed.Load()); got != want {
+				t.Errorf("allowed = %d, want %d", got, want)
+			}
+		})
+	}
+}
@@ -73,3 +73,16 @@ func (r *InMemoryRecommendationRepository) UpdateRecommendation(recommendation *
 	r.recommendations[recommendation.ID] = recommendation
 	return nil
 }
+
+// DeleteRecommendationsByUserID deletes all recommendations for a user
+func (r *InMemoryRecommendationRepository) DeleteRecommendationsByUserID(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, recommendation := range r.recommendations {
+		if recommendation.UserID == userID {
+			delete(r.recommendations, id)
+		}
+	}
+	return nil
+}
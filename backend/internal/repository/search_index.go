@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// Field weights used when scoring matches. Names matter most.
+const (
+	weightName        = 3.0
+	weightAttribute   = 2.0
+	weightDescription = 1.0
+)
+
+// Relative strength of the ways a query token can match an indexed token
+const (
+	matchExact  = 1.0
+	matchPrefix = 0.75
+	matchFuzzy  = 0.5
+)
+
+// searchDoc is the indexed form of an item or outfit
+type searchDoc struct {
+	key     string
+	docType string
+	id      string
+	userID  string
+	tokens  map[string]float64  // token -> highest field weight it appears in
+	facets  map[string][]string // facet name -> values
+}
+
+// InMemorySearchIndex implements SearchIndex with a per-user inverted index.
+// Documents are kept per user too, so a search only ever looks at the
+// searching user's documents.
+type InMemorySearchIndex struct {
+	docs     map[string]map[string]*searchDoc         // userID -> doc key -> doc
+	owners   map[string]string                        // doc key -> userID
+	postings map[string]map[string]map[string]float64 // userID -> token -> doc key -> weight
+	mu       sync.RWMutex
+}
+
+// NewSearchIndex creates a new search index
+func NewSearchIndex() domain.SearchIndex {
+	return &InMemorySearchIndex{
+		docs:     make(map[string]map[string]*searchDoc),
+		owners:   make(map[string]string),
+		postings: make(map[string]map[string]map[string]float64),
+	}
+}
+
+// IndexItem adds or refreshes a clothing item in the index
+func (idx *InMemorySearchIndex) IndexItem(item *domain.ClothingItem) {
+	doc := &searchDoc{
+		key:     docKey(domain.SearchTypeItem, item.ID),
+		docType: domain.SearchTypeItem,
+		id:      item.ID,
+		userID:  item.UserID,
+		tokens:  make(map[string]float64),
+		facets: map[string][]string{
+			"category": {strings.ToLower(item.Category)},
+			"color":    {strings.ToLower(item.Color)},
+			"season":   lowerAll(item.Season),
+		},
+	}
+	doc.addField(item.Name, weightName)
+	doc.addField(item.Brand, weightAttribute)
+	doc.addField(item.Color, weightAttribute)
+	doc.addField(item.Subcategory, weightAttribute)
+	doc.addField(item.Notes, weightDescription)
+
+	idx.put(doc)
+}
+
+// IndexOutfit adds or refreshes an outfit in the index
+func (idx *InMemorySearchIndex) IndexOutfit(outfit *domain.Outfit) {
+	doc := &searchDoc{
+		key:     docKey(domain.SearchTypeOutfit, outfit.ID),
+		docType: domain.SearchTypeOutfit,
+		id:      outfit.ID,
+		userID:  outfit.UserID,
+		tokens:  make(map[string]float64),
+		facets: map[string][]string{
+			"season":   lowerAll(outfit.Season),
+			"occasion": lowerAll(outfit.Occasion),
+		},
+	}
+	doc.addField(outfit.Name, weightName)
+	doc.addField(outfit.Description, weightDescription)
+
+	idx.put(doc)
+}
+
+// Remove deletes a document from the index
+func (idx *InMemorySearchIndex) Remove(docType, id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(docKey(docType, id))
+}
+
+// Search finds the documents matching every query token, applying facet filters
+// and counting facet values across all matches
+func (idx *InMemorySearchIndex) Search(query *domain.SearchQuery) (*domain.SearchResults, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := idx.match(query.UserID, tokenize(query.Text))
+
+	types := make(map[string]bool)
+	for _, t := range query.Types {
+		types[t] = true
+	}
+	filters := map[string]string{
+		"category": strings.ToLower(query.Category),
+		"color":    strings.ToLower(query.Color),
+		"season":   strings.ToLower(query.Season),
+		"occasion": strings.ToLower(query.Occasion),
+	}
+
+	results := &domain.SearchResults{
+		Hits: []*domain.SearchHit{},
+		Facets: map[string]map[string]int{
+			"category": {},
+			"color":    {},
+			"season":   {},
+			"occasion": {},
+		},
+	}
+
+	docs := idx.docs[query.UserID]
+	for key, score := range scores {
+		doc := docs[key]
+		if len(types) > 0 && !types[doc.docType] {
+			continue
+		}
+		if !doc.matchesFacets(filters) {
+			continue
+		}
+
+		for facet, values := range doc.facets {
+			for _, v := range values {
+				if v != "" {
+					results.Facets[facet][v]++
+				}
+			}
+		}
+		results.Hits = append(results.Hits, &domain.SearchHit{
+			Type:  doc.docType,
+			ID:    doc.id,
+			Score: score,
+		})
+	}
+
+	sort.Slice(results.Hits, func(i, j int) bool {
+		if results.Hits[i].Score != results.Hits[j].Score {
+			return results.Hits[i].Score > results.Hits[j].Score
+		}
+		return results.Hits[i].ID < results.Hits[j].ID
+	})
+
+	results.Total = len(results.Hits)
+	if query.Limit > 0 && len(results.Hits) > query.Limit {
+		results.Hits = results.Hits[:query.Limit]
+	}
+	return results, nil
+}
+
+// match scores the user's documents against the query tokens. A document must
+// match every token; with no tokens every document matches with score zero.
+func (idx *InMemorySearchIndex) match(userID string, queryTokens []string) map[string]float64 {
+	postings := idx.postings[userID]
+	scores := make(map[string]float64)
+
+	if len(queryTokens) == 0 {
+		for key := range idx.docs[userID] {
+			scores[key] = 0
+		}
+		return scores
+	}
+
+	for i, q := range queryTokens {
+		best := make(map[string]float64)
+		for token, docs := range postings {
+			strength := matchStrength(q, token)
+			if strength == 0 {
+				continue
+			}
+			for key, weight := range docs {
+				if s := strength * weight; s > best[key] {
+					best[key] = s
+				}
+			}
+		}
+
+		if i == 0 {
+			scores = best
+			continue
+		}
+		for key := range scores {
+			s, ok := best[key]
+			if !ok {
+				delete(scores, key)
+				continue
+			}
+			scores[key] += s
+		}
+	}
+	return scores
+}
+
+func (idx *InMemorySearchIndex) put(doc *searchDoc) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.key)
+	docs, ok := idx.docs[doc.userID]
+	if !ok {
+		docs = make(map[string]*searchDoc)
+		idx.docs[doc.userID] = docs
+	}
+	docs[doc.key] = doc
+	idx.owners[doc.key] = doc.userID
+
+	postings, ok := idx.postings[doc.userID]
+	if !ok {
+		postings = make(map[string]map[string]float64)
+		idx.postings[doc.userID] = postings
+	}
+	for token, weight := range doc.tokens {
+		if _, ok := postings[token]; !ok {
+			postings[token] = make(map[string]float64)
+		}
+		postings[token][doc.key] = weight
+	}
+}
+
+// remove must be called with the write lock held
+func (idx *InMemorySearchIndex) remove(key string) {
+	userID, ok := idx.owners[key]
+	if !ok {
+		return
+	}
+	doc := idx.docs[userID][key]
+
+	postings := idx.postings[userID]
+	for token := range doc.tokens {
+		delete(postings[token], key)
+		if len(postings[token]) == 0 {
+			delete(postings, token)
+		}
+	}
+	if len(postings) == 0 {
+		delete(idx.postings, userID)
+	}
+	delete(idx.docs[userID], key)
+	if len(idx.docs[userID]) == 0 {
+		delete(idx.docs, userID)
+	}
+	delete(idx.owners, key)
+}
+
+func (d *searchDoc) addField(text string, weight float64) {
+	for _, token := range tokenize(text) {
+		if weight > d.tokens[token] {
+			d.tokens[token] = weight
+		}
+	}
+}
+
+func (d *searchDoc) matchesFacets(filters map[string]string) bool {
+	for facet, want := range filters {
+		if want == "" {
+			continue
+		}
+		found := false
+		for _, v := range d.facets[facet] {
+			if v == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func docKey(docType, id string) string {
+	return docType + ":" + id
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+// tokenize splits text into lower-case words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchStrength reports how well query token q matches indexed token t:
+// exactly, as a prefix (while typing), or within a small edit distance (typos)
+func matchStrength(q, t string) float64 {
+	switch {
+	case q == t:
+		return matchExact
+	case len(q) >= 2 && strings.HasPrefix(t, q):
+		return matchPrefix
+	}
+
+	maxEdits := allowedEdits(q)
+	if maxEdits == 0 {
+		return 0
+	}
+	if d := len(t) - len(q); d > maxEdits || d < -maxEdits {
+		return 0
+	}
+	if editDistance(q, t, maxEdits) <= maxEdits {
+		return matchFuzzy
+	}
+	return 0
+}
+
+// allowedEdits returns the typo budget for a query token of a given length
+func allowedEdits(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting an
+// adjacent transposition as one edit. It stops early once the distance exceeds max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// InMemorySessionRepository implements SessionRepository using in-memory storage
+type InMemorySessionRepository struct {
+	sessions map[string]*domain.Session
+	mu       sync.RWMutex
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository() domain.SessionRepository {
+	return &InMemorySessionRepository{
+		sessions: make(map[string]*domain.Session),
+	}
+}
+
+// Touch creates a session or records a request made in it, checking that it
+// hasn't been revoked in the same write
+func (r *InMemorySessionRepository) Touch(session *domain.Session, resolution time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.sessions[session.ID]
+	if !exists {
+		copied := *session
+		copied.Current = false
+		copied.CreatedAt = session.LastSeenAt
+		r.sessions[session.ID] = &copied
+		return nil
+	}
+	if existing.UserID != session.UserID {
+		return domain.ErrSessionNotFound
+	}
+	if existing.RevokedAt != nil {
+		return domain.ErrSessionRevoked
+	}
+
+	updated := *existing
+	changed := session.LastSeenAt.Sub(existing.LastSeenAt) >= resolution
+	if session.ExpiresAt.After(existing.ExpiresAt) {
+		updated.ExpiresAt = session.ExpiresAt
+		changed = true
+	}
+	if session.UserAgent != existing.UserAgent || session.IPAddress != existing.IPAddress {
+		updated.UserAgent = session.UserAgent
+		updated.IPAddress = session.IPAddress
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	updated.LastSeenAt = session.LastSeenAt
+	r.sessions[session.ID] = &updated
+	return nil
+}
+
+// Revoke marks a session revoked
+func (r *InMemorySessionRepository) Revoke(id string, at time.Time) (*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.sessions[id]
+	if !exists {
+		return nil, domain.ErrSessionNotFound
+	}
+	if existing.RevokedAt == nil {
+		updated := *existing
+		updated.RevokedAt = &at
+		r.sessions[id] = &updated
+		existing = &updated
+	}
+	copied := *existing
+	return &copied, nil
+}
+
+// GetByID retrieves a session by ID
+func (r *InMemorySessionRepository) GetByID(id string) (*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, domain.ErrSessionNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// ListByUserID retrieves a user's sessions, most recently used first
+func (r *InMemorySessionRepository) ListByUserID(userID string) ([]*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []*domain.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// ListRevoked returns revoked sessions that haven't expired
+func (r *InMemorySessionRepository) ListRevoked(now time.Time) ([]*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var revoked []*domain.Session
+	for _, session := range r.sessions {
+		if session.RevokedAt != nil && now.Before(session.ExpiresAt) {
+			copied := *session
+			revoked = append(revoked, &copied)
+		}
+	}
+	return revoked, nil
+}
+
+// DeleteByUserID removes all of a user's sessions
+func (r *InMemorySessionRepository) DeleteByUserID(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestSessionRepositoryTouchAfterRevoke(t *testing.T) {
+	const touches = 50
+	now := time.Now()
+
+	repo := NewSessionRepository()
+	session := &domain.Session{ID: "session-1", UserID: "user-1", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := repo.Touch(session, 0); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		revoked = make(chan struct{})
+	)
+	for i := 0; i < touches; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			touch := *session
+			touch.LastSeenAt = now.Add(time.Duration(i+1) * time.Second)
+			touch.ExpiresAt = now.Add(2 * time.Hour)
+
+			// A touch that starts after the revocation has returned must see it
+			var afterRevoke bool
+			select {
+			case <-revoked:
+				afterRevoke = true
+			default:
+			}
+			err := repo.Touch(&touch, 0)
+			if err != nil && !errors.Is(err, domain.ErrSessionRevoked) {
+				t.Error(err)
+			}
+			if afterRevoke && err == nil {
+				t.Error("touch after revocation succeeded")
+			}
+		}(i)
+	}
+	if _, err := repo.Revoke(session.ID, now); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	close(revoked)
+	wg.Wait()
+
+	stored, err := repo.GetByID(session.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if stored.RevokedAt == nil {
+		t.Error("revocation was overwritten by a touch")
+	}
+}
+
+func TestSessionRepositoryTouch(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		touch   domain.Session
+		wantErr error
+	}{
+		{
+			name:  "same user",
+			touch: domain.Session{ID: "session-1", UserID: "user-1", LastSeenAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:    "another user's session",
+			touch:   domain.Session{ID: "session-1", UserID: "user-2", LastSeenAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)},
+			wantErr: domain.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSessionRepository()
+			if err := repo.Touch(&domain.Session{ID: "session-1", UserID: "user-1", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}, 0); err != nil {
+				t.Fatalf("Touch: %v", err)
+			}
+			if err := repo.Touch(&tt.touch, 0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Touch() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
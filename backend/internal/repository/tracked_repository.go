@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// TrackedWardrobeRepository wraps a WardrobeRepository and records every item
+// write in the change log for offline sync
+type TrackedWardrobeRepository struct {
+	domain.WardrobeRepository
+	changes domain.ChangeLog
+}
+
+// NewTrackedWardrobeRepository creates a wardrobe repository that records item changes
+func NewTrackedWardrobeRepository(repo domain.WardrobeRepository, changes domain.ChangeLog) domain.WardrobeRepository {
+	return &TrackedWardrobeRepository{
+		WardrobeRepository: repo,
+		changes:            changes,
+	}
+}
+
+// CreateItem creates a new clothing item and records the change
+func (r *TrackedWardrobeRepository) CreateItem(item *domain.ClothingItem) error {
+	if err := r.WardrobeRepository.CreateItem(item); err != nil {
+		return err
+	}
+	r.changes.Record(item.UserID, domain.SyncTypeItem, item.ID, false)
+	return nil
+}
+
+// UpdateItem updates an existing clothing item and records the change
+func (r *TrackedWardrobeRepository) UpdateItem(item *domain.ClothingItem) error {
+	if err := r.WardrobeRepository.UpdateItem(item); err != nil {
+		return err
+	}
+	r.changes.Record(item.UserID, domain.SyncTypeItem, item.ID, false)
+	return nil
+}
+
+// DeleteItem deletes a clothing item and leaves a tombstone
+func (r *TrackedWardrobeRepository) DeleteItem(id string) error {
+	item, err := r.WardrobeRepository.GetItemByID(id)
+	if err != nil {
+		return err
+	}
+	if err := r.WardrobeRepository.DeleteItem(id); err != nil {
+		return err
+	}
+	r.changes.Record(item.UserID, domain.SyncTypeItem, id, true)
+	return nil
+}
+
+// RecordItemWear counts a wear of an item and records the change
+func (r *TrackedWardrobeRepository) RecordItemWear(id string, wornAt time.Time) error {
+	if err := r.WardrobeRepository.RecordItemWear(id, wornAt); err != nil {
+		return err
+	}
+	if item, err := r.WardrobeRepository.GetItemByID(id); err == nil {
+		r.changes.Record(item.UserID, domain.SyncTypeItem, id, false)
+	}
+	return nil
+}
+
+// WithinTransaction runs fn in a transaction of the wrapped repository and
+// records its changes once it commits
+func (r *TrackedWardrobeRepository) WithinTransaction(fn func(tx domain.WardrobeRepository) error) error {
+	transactor, ok := r.WardrobeRepository.(domain.WardrobeTransactor)
+	if !ok {
+		return domain.ErrTransactionsUnsupported
+	}
+
+	changes := &deferredChangeLog{ChangeLog: r.changes}
+	err := transactor.WithinTransaction(func(tx domain.WardrobeRepository) error {
+		return fn(&TrackedWardrobeRepository{WardrobeRepository: tx, changes: changes})
+	})
+	if err == nil {
+		changes.flush()
+	}
+	return err
+}
+
+// TrackedOutfitRepository wraps an OutfitRepository and records every outfit
+// and reflection write in the change log for offline sync
+type TrackedOutfitRepository struct {
+	domain.OutfitRepository
+	changes domain.ChangeLog
+}
+
+// NewTrackedOutfitRepository creates an outfit repository that records outfit changes
+func NewTrackedOutfitRepository(repo domain.OutfitRepository, changes domain.ChangeLog) domain.OutfitRepository {
+	return &TrackedOutfitRepository{
+		OutfitRepository: repo,
+		changes:          changes,
+	}
+}
+
+// CreateOutfit creates a new outfit and records the change
+func (r *TrackedOutfitRepository) CreateOutfit(outfit *domain.Outfit) error {
+	if err := r.OutfitRepository.CreateOutfit(outfit); err != nil {
+		return err
+	}
+	r.changes.Record(outfit.UserID, domain.SyncTypeOutfit, outfit.ID, false)
+	return nil
+}
+
+// UpdateOutfit updates an existing outfit and records the change
+func (r *TrackedOutfitRepository) UpdateOutfit(outfit *domain.Outfit) error {
+	if err := r.OutfitRepository.UpdateOutfit(outfit); err != nil {
+		return err
+	}
+	r.changes.Record(outfit.UserID, domain.SyncTypeOutfit, outfit.ID, false)
+	return nil
+}
+
+// DeleteOutfit deletes an outfit and leaves a tombstone
+func (r *TrackedOutfitRepository) DeleteOutfit(id string) error {
+	outfit, err := r.OutfitRepository.GetOutfitByID(id)
+	if err != nil {
+		return err
+	}
+	if err := r.OutfitRepository.DeleteOutfit(id); err != nil {
+		return err
+	}
+	r.changes.Record(outfit.UserID, domain.SyncTypeOutfit, id, true)
+	return nil
+}
+
+// SetFavorite updates an outfit's favorite status and records the change
+func (r *TrackedOutfitRepository) SetFavorite(id string, favorite bool) error {
+	if err := r.OutfitRepository.SetFavorite(id, favorite); err != nil {
+		return err
+	}
+	if outfit, err := r.OutfitRepository.GetOutfitByID(id); err == nil {
+		r.changes.Record(outfit.UserID, domain.SyncTypeOutfit, id, false)
+	}
+	return nil
+}
+
+// CreateReflection creates a new reflection and records it, along with the
+// wear it added to its outfit
+func (r *TrackedOutfitRepository) CreateReflection(reflection *domain.Reflection) error {
+	if err := r.OutfitRepository.CreateReflection(reflection); err != nil {
+		return err
+	}
+	r.changes.Record(reflection.UserID, domain.SyncTypeReflection, reflection.ID, false)
+	r.changes.Record(reflection.UserID, domain.SyncTypeOutfit, reflection.OutfitID, false)
+	return nil
+}
+
+// TrackedUserRepository wraps a UserRepository and records profile and style
+// profile writes in the change log for offline sync
+type TrackedUserRepository struct {
+	domain.UserRepository
+	changes domain.ChangeLog
+}
+
+// NewTrackedUserRepository creates a user repository that records profile changes
+func NewTrackedUserRepository(repo domain.UserRepository, changes domain.ChangeLog) domain.UserRepository {
+	return &TrackedUserRepository{
+		UserRepository: repo,
+		changes:        changes,
+	}
+}
+
+// Update updates a user and records the profile change
+func (r *TrackedUserRepository) Update(user *domain.User) error {
+	if err := r.UserRepository.Update(user); err != nil {
+		return err
+	}
+	r.changes.Record(user.ID, domain.SyncTypeProfile, user.ID, false)
+	return nil
+}
+
+// SaveStyleProfile saves a style profile and records the change
+func (r *TrackedUserRepository) SaveStyleProfile(profile *domain.StyleProfile) error {
+	if err := r.UserRepository.SaveStyleProfile(profile); err != nil {
+		return err
+	}
+	r.changes.Record(profile.UserID, domain.SyncTypeStyleProfile, profile.ID, false)
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,9 +10,13 @@ import (
 	"github.com/lilo/backend/internal/domain"
 )
 
-// InMemoryUserRepository implements UserRepository using in-memory storage
+// InMemoryUserRepository implements UserRepository using in-memory storage.
+// The Supabase ID and email indexes double as unique constraints.
 type InMemoryUserRepository struct {
 	users         map[string]*domain.User
+	bySupabaseID  map[string]string
+	byEmail       map[string]string
+	blocked       map[string]time.Time // Supabase IDs deleted by the provider -> blocked until
 	styleProfiles map[string]*domain.StyleProfile
 	mu            sync.RWMutex
 }
@@ -20,15 +25,69 @@ type InMemoryUserRepository struct {
 func NewUserRepository() domain.UserRepository {
 	return &InMemoryUserRepository{
 		users:         make(map[string]*domain.User),
+		bySupabaseID:  make(map[string]string),
+		byEmail:       make(map[string]string),
+		blocked:       make(map[string]time.Time),
 		styleProfiles: make(map[string]*domain.StyleProfile),
 	}
 }
 
+// emailKey normalizes an email for the unique index
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkUnique reports whether another user already has the user's Supabase
+// ID or email, or the Supabase ID is blocked. The caller must hold the lock.
+func (r *InMemoryUserRepository) checkUnique(user *domain.User) error {
+	if user.SupabaseID != "" {
+		if until, exists := r.blocked[user.SupabaseID]; exists {
+			if time.Now().Before(until) {
+				return domain.ErrUserDeleted
+			}
+			delete(r.blocked, user.SupabaseID)
+		}
+		if id, exists := r.bySupabaseID[user.SupabaseID]; exists && id != user.ID {
+			return domain.ErrUserExists
+		}
+	}
+	if key := emailKey(user.Email); key != "" {
+		if id, exists := r.byEmail[key]; exists && id != user.ID {
+			return domain.ErrEmailTaken
+		}
+	}
+	return nil
+}
+
+// index adds the user to the lookup indexes. The caller must hold the lock.
+func (r *InMemoryUserRepository) index(user *domain.User) {
+	if user.SupabaseID != "" {
+		r.bySupabaseID[user.SupabaseID] = user.ID
+	}
+	if key := emailKey(user.Email); key != "" {
+		r.byEmail[key] = user.ID
+	}
+}
+
+// unindex removes the user from the lookup indexes. The caller must hold the lock.
+func (r *InMemoryUserRepository) unindex(user *domain.User) {
+	if r.bySupabaseID[user.SupabaseID] == user.ID {
+		delete(r.bySupabaseID, user.SupabaseID)
+	}
+	if key := emailKey(user.Email); r.byEmail[key] == user.ID {
+		delete(r.byEmail, key)
+	}
+}
+
 // Create creates a new user
 func (r *InMemoryUserRepository) Create(user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+
 	if user.ID == "" {
 		user.ID = uuid.New().String()
 	}
@@ -36,6 +95,7 @@ func (r *InMemoryUserRepository) Create(user *domain.User) error {
 	user.UpdatedAt = time.Now()
 
 	r.users[user.ID] = user
+	r.index(user)
 	return nil
 }
 
@@ -56,12 +116,11 @@ func (r *InMemoryUserRepository) GetByEmail(email string) (*domain.User, error)
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, user := range r.users {
-		if user.Email == email {
-			return user, nil
-		}
+	id, exists := r.byEmail[emailKey(email)]
+	if !exists {
+		return nil, domain.ErrUserNotFound
 	}
-	return nil, domain.ErrUserNotFound
+	return r.users[id], nil
 }
 
 // GetBySupabaseID retrieves a user by Supabase ID
@@ -69,12 +128,11 @@ func (r *InMemoryUserRepository) GetBySupabaseID(supabaseID string) (*domain.Use
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, user := range r.users {
-		if user.SupabaseID == supabaseID {
-			return user, nil
-		}
+	id, exists := r.bySupabaseID[supabaseID]
+	if !exists {
+		return nil, domain.ErrUserNotFound
 	}
-	return nil, domain.ErrUserNotFound
+	return r.users[id], nil
 }
 
 // Update updates an existing user
@@ -82,12 +140,56 @@ func (r *InMemoryUserRepository) Update(user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.users[user.ID]; !exists {
+	existing, exists := r.users[user.ID]
+	if !exists {
 		return domain.ErrUserNotFound
 	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
 
+	user.DisabledAt = existing.DisabledAt
+	user.SessionsRevokedAt = existing.SessionsRevokedAt
 	user.UpdatedAt = time.Now()
+	r.unindex(existing)
 	r.users[user.ID] = user
+	r.index(user)
+	return nil
+}
+
+// SetDisabledAt disables a user, or re-enables them with a nil time
+func (r *InMemoryUserRepository) SetDisabledAt(id string, disabledAt *time.Time) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[id]
+	if !exists {
+		return nil, domain.ErrUserNotFound
+	}
+
+	// Replace rather than modify the record, since readers hold pointers to it
+	updated := *existing
+	updated.DisabledAt = disabledAt
+	updated.UpdatedAt = time.Now()
+	r.users[id] = &updated
+	return &updated, nil
+}
+
+// SetSessionsRevokedAt rejects the user's tokens issued before revokedAt
+func (r *InMemoryUserRepository) SetSessionsRevokedAt(id string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[id]
+	if !exists {
+		return domain.ErrUserNotFound
+	}
+
+	// Replace rather than modify the record, since readers hold pointers to it
+	updated := *existing
+	updated.SessionsRevokedAt = &revokedAt
+	updated.UpdatedAt = time.Now()
+	r.users[id] = &updated
 	return nil
 }
 
@@ -96,15 +198,59 @@ func (r *InMemoryUserRepository) Delete(id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.users[id]; !exists {
+	user, exists := r.users[id]
+	if !exists {
 		return domain.ErrUserNotFound
 	}
 
+	r.unindex(user)
 	delete(r.users, id)
 	delete(r.styleProfiles, id) // Also delete style profile
 	return nil
 }
 
+// BlockSupabaseID refuses to create a user with a Supabase ID until the given
+// time. Expired blocks are dropped as new ones are added, so only the recent
+// deletions are kept.
+func (r *InMemoryUserRepository) BlockSupabaseID(supabaseID string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, blockedUntil := range r.blocked {
+		if !now.Before(blockedUntil) {
+			delete(r.blocked, id)
+		}
+	}
+	if until.After(r.blocked[supabaseID]) {
+		r.blocked[supabaseID] = until
+	}
+	return nil
+}
+
+// ListUsers retrieves one sorted page of the users matching a query
+func (r *InMemoryUserRepository) ListUsers(query domain.UserQuery, page domain.PageRequest) ([]*domain.User, *domain.PageInfo, error) {
+	r.mu.RLock()
+	search := strings.ToLower(strings.TrimSpace(query.Search))
+	var users []*domain.User
+	for _, user := range r.users {
+		if search != "" && !strings.Contains(strings.ToLower(user.Email), search) &&
+			!strings.Contains(strings.ToLower(user.Name), search) {
+			continue
+		}
+		if query.Role != "" && user.Role != query.Role {
+			continue
+		}
+		if query.Disabled != nil && user.IsDisabled() != *query.Disabled {
+			continue
+		}
+		users = append(users, user)
+	}
+	r.mu.RUnlock()
+
+	return paginate(users, userSortKey, page)
+}
+
 // GetStyleProfile retrieves a user's style profile
 func (r *InMemoryUserRepository) GetStyleProfile(userID string) (*domain.StyleProfile, error) {
 	r.mu.RLock()
@@ -122,9 +268,19 @@ func (r *InMemoryUserRepository) SaveStyleProfile(profile *domain.StyleProfile)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if existing, exists := r.styleProfiles[profile.UserID]; exists {
+		if profile.Version != existing.Version {
+			return domain.ErrVersionConflict
+		}
+		profile.ID = existing.ID
+	} else {
+		profile.Version = 0
+	}
+
 	if profile.ID == "" {
 		profile.ID = uuid.New().String()
 	}
+	profile.Version++
 	profile.UpdatedAt = time.Now()
 
 	r.styleProfiles[profile.UserID] = profile
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestUserRepositoryUpdateKeepsAccessChanges(t *testing.T) {
+	const updates = 50
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		write func(repo domain.UserRepository, id string) error
+		check func(user *domain.User) bool
+	}{
+		{
+			name: "disabling",
+			write: func(repo domain.UserRepository, id string) error {
+				_, err := repo.SetDisabledAt(id, &now)
+				return err
+			},
+			check: func(user *domain.User) bool { return user.DisabledAt != nil },
+		},
+		{
+			name: "signing out everywhere",
+			write: func(repo domain.UserRepository, id string) error {
+				return repo.SetSessionsRevokedAt(id, now)
+			},
+			check: func(user *domain.User) bool { return user.SessionsRevokedAt != nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewUserRepository()
+			user := &domain.User{SupabaseID: "supabase-1", Email: "user@example.com"}
+			if err := repo.Create(user); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			// Profile updates start from a copy read before the access change
+			profile := *user
+
+			var wg sync.WaitGroup
+			for i := 0; i < updates; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					updated := profile
+					updated.Name = "Renamed"
+					if err := repo.Update(&updated); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			if err := tt.write(repo, user.ID); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			wg.Wait()
+
+			stored, err := repo.GetByID(user.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if !tt.check(stored) {
+				t.Errorf("%s was undone by a profile update", tt.name)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryBlockedSupabaseIDs(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, repo domain.UserRepository)
+		wantErr error
+	}{
+		{
+			name: "deleted in the app",
+			setup: func(t *testing.T, repo domain.UserRepository) {
+				user := &domain.User{SupabaseID: "supabase-1", Email: "user@example.com"}
+				if err := repo.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := repo.Delete(user.ID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+			},
+		},
+		{
+			name: "deleted by the provider",
+			setup: func(t *testing.T, repo domain.UserRepository) {
+				if err := repo.BlockSupabaseID("supabase-1", now.Add(time.Minute)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+			},
+			wantErr: domain.ErrUserDeleted,
+		},
+		{
+			name: "block has expired",
+			setup: func(t *testing.T, repo domain.UserRepository) {
+				if err := repo.BlockSupabaseID("supabase-1", now.Add(-time.Minute)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+			},
+		},
+		{
+			name: "a shorter block doesn't cut a longer one short",
+			setup: func(t *testing.T, repo domain.UserRepository) {
+				if err := repo.BlockSupabaseID("supabase-1", now.Add(time.Hour)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+				if err := repo.BlockSupabaseID("supabase-1", now.Add(-time.Minute)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+			},
+			wantErr: domain.ErrUserDeleted,
+		},
+		{
+			name: "another identity is blocked",
+			setup: func(t *testing.T, repo domain.UserRepository) {
+				if err := repo.BlockSupabaseID("supabase-2", now.Add(time.Minute)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewUserRepository()
+			tt.setup(t, repo)
+			err := repo.Create(&domain.User{SupabaseID: "supabase-1", Email: "user@example.com"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -10,15 +10,18 @@ import (
 
 // InMemoryWardrobeRepository implements WardrobeRepository using in-memory storage
 type InMemoryWardrobeRepository struct {
-	items      map[string]*domain.ClothingItem
-	categories []*domain.ClothingCategory
-	mu         sync.RWMutex
+	items          map[string]*domain.ClothingItem
+	categories     []*domain.ClothingCategory
+	userCategories map[string]map[string]*domain.ClothingCategory // userID -> category ID -> category
+	mu             sync.RWMutex
+	txMu           sync.Mutex // serializes transactions
 }
 
 // NewWardrobeRepository creates a new wardrobe repository
 func NewWardrobeRepository() domain.WardrobeRepository {
 	repo := &InMemoryWardrobeRepository{
-		items: make(map[string]*domain.ClothingItem),
+		items:          make(map[string]*domain.ClothingItem),
+		userCategories: make(map[string]map[string]*domain.ClothingCategory),
 	}
 
 	// Initialize default categories
@@ -71,6 +74,7 @@ func (r *InMemoryWardrobeRepository) CreateItem(item *domain.ClothingItem) error
 	if item.ID == "" {
 		item.ID = uuid.New().String()
 	}
+	item.Version = 1
 	item.CreatedAt = time.Now()
 	item.UpdatedAt = time.Now()
 
@@ -85,13 +89,13 @@ func (r *InMemoryWardrobeRepository) GetItemByID(id string) (*domain.ClothingIte
 
 	item, exists := r.items[id]
 	if !exists {
-		return nil, domain.ErrUserNotFound // Reusing error for simplicity
+		return nil, domain.ErrItemNotFound
 	}
 	return item, nil
 }
 
-// GetItemsByUserID retrieves all clothing items for a user with optional filters
-func (r *InMemoryWardrobeRepository) GetItemsByUserID(userID string, filters map[string]interface{}) ([]*domain.ClothingItem, error) {
+// GetItemsByUserID retrieves all clothing items for a user matching a query
+func (r *InMemoryWardrobeRepository) GetItemsByUserID(userID string, query domain.WardrobeQuery) ([]*domain.ClothingItem, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -99,7 +103,7 @@ func (r *InMemoryWardrobeRepository) GetItemsByUserID(userID string, filters map
 	for _, item := range r.items {
 		if item.UserID == userID {
 			// Apply filters if provided
-			if r.matchesFilters(item, filters) {
+			if r.matchesFilters(item, query) {
 				items = append(items, item)
 			}
 		}
@@ -107,45 +111,35 @@ func (r *InMemoryWardrobeRepository) GetItemsByUserID(userID string, filters map
 	return items, nil
 }
 
-// matchesFilters checks if an item matches the provided filters
-func (r *InMemoryWardrobeRepository) matchesFilters(item *domain.ClothingItem, filters map[string]interface{}) bool {
-	if filters == nil {
-		return true
-	}
-
-	if category, ok := filters["category"]; ok {
-		if item.Category != category.(string) {
-			return false
-		}
-	}
-
-	if color, ok := filters["color"]; ok {
-		if item.Color != color.(string) {
-			return false
-		}
-	}
-
-	if isOwned, ok := filters["isOwned"]; ok {
-		if item.IsOwned != isOwned.(bool) {
-			return false
-		}
+// ListItemsByUserID retrieves one sorted page of a user's clothing items matching a query
+func (r *InMemoryWardrobeRepository) ListItemsByUserID(userID string, query domain.WardrobeQuery, page domain.PageRequest) ([]*domain.ClothingItem, *domain.PageInfo, error) {
+	items, err := r.GetItemsByUserID(userID, query)
+	if err != nil {
+		return nil, nil, err
 	}
+	return paginate(items, itemSortKey, page)
+}
 
-	if season, ok := filters["season"]; ok {
-		seasonStr := season.(string)
-		found := false
-		for _, s := range item.Season {
-			if s == seasonStr {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
+// matchesFilters checks if an item matches the provided query
+func (r *InMemoryWardrobeRepository) matchesFilters(item *domain.ClothingItem, query domain.WardrobeQuery) bool {
+	if query.IsOwned != nil && item.IsOwned != *query.IsOwned {
+		return false
 	}
 
-	return true
+	return query.Category.Matches(item.Category) &&
+		query.Color.Matches(item.Color) &&
+		query.Season.MatchesAny(item.Season) &&
+		query.Status.Matches(string(item.CurrentStatus())) &&
+		query.Material.Matches(item.Material) &&
+		query.Pattern.Matches(item.Pattern) &&
+		query.Fit.Matches(item.Fit) &&
+		query.Waterproof.Matches(item.Waterproof) &&
+		query.Care.MatchesAny(item.CareInstructions) &&
+		query.Formality.Matches(item.Formality) &&
+		query.Warmth.Matches(item.Warmth) &&
+		query.Created.Matches(&item.CreatedAt) &&
+		query.Purchased.Matches(item.PurchaseDate) &&
+		query.LastWorn.Matches(item.LastWornAt)
 }
 
 // UpdateItem updates an existing clothing item
@@ -153,22 +147,48 @@ func (r *InMemoryWardrobeRepository) UpdateItem(item *domain.ClothingItem) error
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.items[item.ID]; !exists {
-		return domain.ErrUserNotFound // Reusing error for simplicity
+	existing, exists := r.items[item.ID]
+	if !exists {
+		return domain.ErrItemNotFound
+	}
+	if item.Version != existing.Version {
+		return domain.ErrVersionConflict
 	}
 
+	item.Version++
 	item.UpdatedAt = time.Now()
 	r.items[item.ID] = item
 	return nil
 }
 
+// RecordItemWear bumps an item's wear count and last worn time in place
+func (r *InMemoryWardrobeRepository) RecordItemWear(id string, wornAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, exists := r.items[id]
+	if !exists {
+		return domain.ErrItemNotFound
+	}
+
+	worn := *item
+	worn.WearCount++
+	if worn.LastWornAt == nil || wornAt.After(*worn.LastWornAt) {
+		worn.LastWornAt = &wornAt
+	}
+	worn.Version++
+	worn.UpdatedAt = time.Now()
+	r.items[id] = &worn
+	return nil
+}
+
 // DeleteItem deletes a clothing item by ID
 func (r *InMemoryWardrobeRepository) DeleteItem(id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.items[id]; !exists {
-		return domain.ErrUserNotFound // Reusing error for simplicity
+		return domain.ErrItemNotFound
 	}
 
 	delete(r.items, id)
@@ -182,3 +202,81 @@ func (r *InMemoryWardrobeRepository) GetCategories() ([]*domain.ClothingCategory
 
 	return r.categories, nil
 }
+
+// SaveCategory creates or replaces a built-in category. The list is copied
+// rather than changed in place, since callers may still be reading the old one.
+func (r *InMemoryWardrobeRepository) SaveCategory(category *domain.ClothingCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	categories := make([]*domain.ClothingCategory, 0, len(r.categories)+1)
+	replaced := false
+	for _, existing := range r.categories {
+		if existing.ID == category.ID {
+			categories = append(categories, category)
+			replaced = true
+		} else {
+			categories = append(categories, existing)
+		}
+	}
+	if !replaced {
+		categories = append(categories, category)
+	}
+	r.categories = categories
+	return nil
+}
+
+// DeleteCategory deletes a built-in category
+func (r *InMemoryWardrobeRepository) DeleteCategory(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	categories := make([]*domain.ClothingCategory, 0, len(r.categories))
+	for _, existing := range r.categories {
+		if existing.ID != id {
+			categories = append(categories, existing)
+		}
+	}
+	if len(categories) == len(r.categories) {
+		return domain.ErrCategoryNotFound
+	}
+	r.categories = categories
+	return nil
+}
+
+// GetUserCategories retrieves a user's personal categories and subcategory additions
+func (r *InMemoryWardrobeRepository) GetUserCategories(userID string) ([]*domain.ClothingCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []*domain.ClothingCategory
+	for _, category := range r.userCategories[userID] {
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// SaveUserCategory creates or replaces a user's personal category
+func (r *InMemoryWardrobeRepository) SaveUserCategory(category *domain.ClothingCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.userCategories[category.UserID]; !exists {
+		r.userCategories[category.UserID] = make(map[string]*domain.ClothingCategory)
+	}
+	r.userCategories[category.UserID][category.ID] = category
+	return nil
+}
+
+// DeleteUserCategory deletes a user's personal category
+func (r *InMemoryWardrobeRepository) DeleteUserCategory(userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.userCategories[userID][id]; !exists {
+		return domain.ErrCategoryNotFound
+	}
+
+	delete(r.userCategories[userID], id)
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+func TestWardrobeRepositoryConcurrentWrites(t *testing.T) {
+	const writers = 50
+
+	tests := []struct {
+		name string
+		// write runs in each writer; it reports whether the write was applied
+		write       func(repo domain.WardrobeRepository, item *domain.ClothingItem, i int) (bool, error)
+		wantApplied int
+		wantWears   int
+	}{
+		{
+			name: "every wear is counted",
+			write: func(repo domain.WardrobeRepository, item *domain.ClothingItem, i int) (bool, error) {
+				return true, repo.RecordItemWear(item.ID, time.Now())
+			},
+			wantApplied: writers,
+			wantWears:   writers,
+		},
+		{
+			name: "only one update of a version wins",
+			write: func(repo domain.WardrobeRepository, item *domain.ClothingItem, i int) (bool, error) {
+				updated := *item
+				updated.Name = "renamed"
+				err := repo.UpdateItem(&updated)
+				if errors.Is(err, domain.ErrVersionConflict) {
+					return false, nil
+				}
+				return err == nil, err
+			},
+			wantApplied: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewWardrobeRepository()
+			created := &domain.ClothingItem{UserID: "user-1", Name: "Shirt"}
+			if err := repo.CreateItem(created); err != nil {
+				t.Fatalf("CreateItem: %v", err)
+			}
+			item := *created
+
+			var (
+				wg      sync.WaitGroup
+				mu      sync.Mutex
+				applied int
+			)
+			for i := 0; i < writers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ok, err := tt.write(repo, &item, i)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						mu.Lock()
+						applied++
+						mu.Unlock()
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if applied != tt.wantApplied {
+				t.Errorf("applied writes = %d, want %d", applied, tt.wantApplied)
+			}
+			stored, err := repo.GetItemByID(item.ID)
+			if err != nil {
+				t.Fatalf("GetItemByID: %v", err)
+			}
+			if stored.WearCount != tt.wantWears {
+				t.Errorf("wear count = %d, want %d", stored.WearCount, tt.wantWears)
+			}
+			if want := item.Version + int64(tt.wantApplied); stored.Version != want {
+				t.Errorf("version = %d, want %d", stored.Version, want)
+			}
+		})
+	}
+}
+
+func TestWardrobeRepositoryMissingItem(t *testing.T) {
+	repo := NewWardrobeRepository()
+
+	tests := []struct {
+		name  string
+		write func() error
+	}{
+		{name: "record wear", write: func() error { return repo.RecordItemWear("missing", time.Now()) }},
+		{name: "update", write: func() error { return repo.UpdateItem(&domain.ClothingItem{ID: "missing"}) }},
+		{name: "delete", write: func() error { return repo.DeleteItem("missing") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); !errors.Is(err, domain.ErrItemNotFound) {
+				t.Errorf("error = %v, want %v", err, domain.ErrItemNotFound)
+			}
+		})
+	}
+}
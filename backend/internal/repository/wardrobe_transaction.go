@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"github.com/lilo/backend/internal/domain"
+)
+
+// WithinTransaction runs fn with a repository that undoes its item writes if fn
+// fails. Transactions run one at a time. Their writes are visible to other
+// requests as they happen, so this gives all-or-nothing results but not
+// isolation; a rollback leaves alone any item another request has since changed.
+func (r *InMemoryWardrobeRepository) WithinTransaction(fn func(tx domain.WardrobeRepository) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	tx := &wardrobeTx{WardrobeRepository: r, repo: r}
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	return nil
+}
+
+// wardrobeTx records how to undo each item write made through it
+type wardrobeTx struct {
+	domain.WardrobeRepository
+	repo *InMemoryWardrobeRepository
+	undo []func()
+}
+
+// CreateItem creates an item, to be deleted on rollback
+func (tx *wardrobeTx) CreateItem(item *domain.ClothingItem) error {
+	if err := tx.WardrobeRepository.CreateItem(item); err != nil {
+		return err
+	}
+	id, version := item.ID, item.Version
+	tx.undo = append(tx.undo, func() {
+		if current, exists := tx.repo.items[id]; exists && current.Version == version {
+			delete(tx.repo.items, id)
+		}
+	})
+	return nil
+}
+
+// UpdateItem updates an item, to be restored on rollback
+func (tx *wardrobeTx) UpdateItem(item *domain.ClothingItem) error {
+	previous, err := tx.copyOf(item.ID)
+	if err != nil {
+		return err
+	}
+	if err := tx.WardrobeRepository.UpdateItem(item); err != nil {
+		return err
+	}
+	id, version := item.ID, item.Version
+	tx.undo = append(tx.undo, func() {
+		if current, exists := tx.repo.items[id]; exists && current.Version == version {
+			tx.repo.items[id] = previous
+		}
+	})
+	return nil
+}
+
+// DeleteItem deletes an item, to be put back on rollback
+func (tx *wardrobeTx) DeleteItem(id string) error {
+	previous, err := tx.copyOf(id)
+	if err != nil {
+		return err
+	}
+	if err := tx.WardrobeRepository.DeleteItem(id); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() {
+		if _, exists := tx.repo.items[id]; !exists {
+			tx.repo.items[id] = previous
+		}
+	})
+	return nil
+}
+
+// SaveUserCategory saves a personal category, to be restored on rollback
+func (tx *wardrobeTx) SaveUserCategory(category *domain.ClothingCategory) error {
+	userID, id := category.UserID, category.ID
+	previous := tx.categoryCopyOf(userID, id)
+	if err := tx.WardrobeRepository.SaveUserCategory(category); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() {
+		if previous == nil {
+			delete(tx.repo.userCategories[userID], id)
+			return
+		}
+		tx.repo.userCategories[userID][id] = previous
+	})
+	return nil
+}
+
+// DeleteUserCategory deletes a personal category, to be put back on rollback
+func (tx *wardrobeTx) DeleteUserCategory(userID, id string) error {
+	previous := tx.categoryCopyOf(userID, id)
+	if err := tx.WardrobeRepository.DeleteUserCategory(userID, id); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() {
+		if previous == nil {
+			return
+		}
+		if _, exists := tx.repo.userCategories[userID]; !exists {
+			tx.repo.userCategories[userID] = make(map[string]*domain.ClothingCategory)
+		}
+		tx.repo.userCategories[userID][id] = previous
+	})
+	return nil
+}
+
+// categoryCopyOf snapshots a stored personal category, or returns nil if there isn't one
+func (tx *wardrobeTx) categoryCopyOf(userID, id string) *domain.ClothingCategory {
+	tx.repo.mu.RLock()
+	defer tx.repo.mu.RUnlock()
+
+	category, exists := tx.repo.userCategories[userID][id]
+	if !exists {
+		return nil
+	}
+	copied := *category
+	copied.Subcategories = append([]string{}, category.Subcategories...)
+	return &copied
+}
+
+// copyOf snapshots the stored item, since callers may modify it in place
+func (tx *wardrobeTx) copyOf(id string) (*domain.ClothingItem, error) {
+	item, err := tx.WardrobeRepository.GetItemByID(id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *item
+	return &copied, nil
+}
+
+// rollback undoes the transaction's writes, newest first
+func (tx *wardrobeTx) rollback() {
+	tx.repo.mu.Lock()
+	defer tx.repo.mu.Unlock()
+
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
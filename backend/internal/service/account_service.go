@@ -0,0 +1,446 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AccountServiceImpl implements AccountService. It works on the repositories
+// directly, since erasing an account has to reach data no single service owns.
+type AccountServiceImpl struct {
+	userRepo           domain.UserRepository
+	wardrobeRepo       domain.WardrobeRepository
+	outfitRepo         domain.OutfitRepository
+	recommendationRepo domain.RecommendationRepository
+	changes            domain.ChangeLog
+	idempotency        domain.IdempotencyStore
+	apiKeys            domain.APIKeyRepository
+	sessions           domain.SessionRepository
+	deletions          domain.DeletionRepository
+	audit              domain.AuditLog
+	images             domain.ImageStore
+	gracePeriod        time.Duration
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(
+	userRepo domain.UserRepository,
+	wardrobeRepo domain.WardrobeRepository,
+	outfitRepo domain.OutfitRepository,
+	recommendationRepo domain.RecommendationRepository,
+	changes domain.ChangeLog,
+	idempotency domain.IdempotencyStore,
+	apiKeys domain.APIKeyRepository,
+	sessions domain.SessionRepository,
+	deletions domain.DeletionRepository,
+	audit domain.AuditLog,
+	images domain.ImageStore,
+	gracePeriod time.Duration,
+) domain.AccountService {
+	return &AccountServiceImpl{
+		userRepo:           userRepo,
+		wardrobeRepo:       wardrobeRepo,
+		outfitRepo:         outfitRepo,
+		recommendationRepo: recommendationRepo,
+		changes:            changes,
+		idempotency:        idempotency,
+		apiKeys:            apiKeys,
+		sessions:           sessions,
+		deletions:          deletions,
+		audit:              audit,
+		images:             images,
+		gracePeriod:        gracePeriod,
+	}
+}
+
+// ExportAccount writes a zip archive holding account.json, with every record
+// kept about the user, and an images folder with copies of their stored images
+func (s *AccountServiceImpl) ExportAccount(userID string, w io.Writer) error {
+	export, err := s.collect(userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for i, imageURL := range imageURLs(export.Profile, export.Items, export.Outfits) {
+		image := &domain.ExportedImage{URL: imageURL}
+		export.Images = append(export.Images, image)
+
+		data, contentType, err := s.images.Fetch(userID, imageURL)
+		if errors.Is(err, domain.ErrImageNotStored) {
+			image.External = true
+			continue
+		}
+		if err != nil {
+			image.Error = err.Error()
+			continue
+		}
+
+		image.Path = fmt.Sprintf("images/%d%s", i+1, imageExtension(imageURL, contentType))
+		file, err := archive.Create(image.Path)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", image.Path, err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", image.Path, err)
+		}
+	}
+
+	file, err := archive.Create("account.json")
+	if err != nil {
+		return fmt.Errorf("failed to write account.json: %w", err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("failed to write account.json: %w", err)
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	s.record(userID, domain.AuditAccountExported, map[string]int{
+		"items":       len(export.Items),
+		"outfits":     len(export.Outfits),
+		"reflections": len(export.Reflections),
+		"images":      len(export.Images),
+	}, nil)
+	return nil
+}
+
+// collect gathers everything stored about a user, oldest records first
+func (s *AccountServiceImpl) collect(userID string) (*domain.AccountExport, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &domain.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    user,
+		Images:     []*domain.ExportedImage{},
+	}
+
+	// A user who never saved a style profile has none
+	if profile, err := s.userRepo.GetStyleProfile(userID); err == nil {
+		export.StyleProfile = profile
+	}
+	if export.Categories, err = s.wardrobeRepo.GetUserCategories(userID); err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	if export.Items, err = s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{}); err != nil {
+		return nil, fmt.Errorf("failed to get wardrobe items: %w", err)
+	}
+	if export.Outfits, err = s.outfitRepo.GetOutfitsByUserID(userID, domain.OutfitQuery{}); err != nil {
+		return nil, fmt.Errorf("failed to get outfits: %w", err)
+	}
+	if export.Reflections, err = s.outfitRepo.GetReflectionsByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to get reflections: %w", err)
+	}
+	if export.Recommendations, err = s.recommendationRepo.GetRecommendationsByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	if export.APIKeys, err = s.apiKeys.ListByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	if export.Sessions, err = s.sessions.ListByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	if export.AuditLog, err = s.audit.ListByUserID(userID); err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	if deletion, err := s.deletions.GetByUserID(userID); err == nil {
+		export.Deletion = deletion
+	}
+
+	if export.Categories == nil {
+		export.Categories = []*domain.ClothingCategory{}
+	}
+	if export.Items == nil {
+		export.Items = []*domain.ClothingItem{}
+	}
+	if export.Outfits == nil {
+		export.Outfits = []*domain.Outfit{}
+	}
+	if export.Reflections == nil {
+		export.Reflections = []*domain.Reflection{}
+	}
+	if export.Recommendations == nil {
+		export.Recommendations = []*domain.Recommendation{}
+	}
+	sort.Slice(export.Items, func(i, j int) bool { return export.Items[i].CreatedAt.Before(export.Items[j].CreatedAt) })
+	sort.Slice(export.Outfits, func(i, j int) bool { return export.Outfits[i].CreatedAt.Before(export.Outfits[j].CreatedAt) })
+	sort.Slice(export.Reflections, func(i, j int) bool {
+		return export.Reflections[i].CreatedAt.Before(export.Reflections[j].CreatedAt)
+	})
+	sort.Slice(export.Recommendations, func(i, j int) bool {
+		return export.Recommendations[i].CreatedAt.Before(export.Recommendations[j].CreatedAt)
+	})
+	return export, nil
+}
+
+// RequestDeletion schedules the user's account to be erased once the grace
+// period has passed
+func (s *AccountServiceImpl) RequestDeletion(userID string) (*domain.AccountDeletion, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, err
+	}
+	if existing, err := s.deletions.GetByUserID(userID); err == nil && existing.Status == domain.DeletionStatusPending {
+		return existing, domain.ErrDeletionPending
+	}
+
+	now := time.Now()
+	deletion := &domain.AccountDeletion{
+		UserID:       userID,
+		Status:       domain.DeletionStatusPending,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(s.gracePeriod),
+	}
+	if err := s.deletions.Save(deletion); err != nil {
+		return nil, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	s.record(userID, domain.AuditAccountDeletionRequested, nil, nil)
+	return deletion, nil
+}
+
+// CancelDeletion keeps an account whose deletion is still pending
+func (s *AccountServiceImpl) CancelDeletion(userID string) (*domain.AccountDeletion, error) {
+	deletion, err := s.deletions.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if deletion.Status != domain.DeletionStatusPending {
+		return nil, domain.ErrDeletionNotFound
+	}
+
+	now := time.Now()
+	deletion.Status = domain.DeletionStatusCancelled
+	deletion.CancelledAt = &now
+	if err := s.deletions.Save(deletion); err != nil {
+		return nil, fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+	s.record(userID, domain.AuditAccountDeletionCancelled, nil, nil)
+	return deletion, nil
+}
+
+// GetDeletion returns the user's latest deletion request
+func (s *AccountServiceImpl) GetDeletion(userID string) (*domain.AccountDeletion, error) {
+	return s.deletions.GetByUserID(userID)
+}
+
+// DeleteAccount erases an account immediately. If it fails part way, the
+// deletion is left pending with the error and DeleteDueAccounts retries it.
+func (s *AccountServiceImpl) DeleteAccount(userID string) error {
+	now := time.Now()
+	deletion := &domain.AccountDeletion{
+		UserID:       userID,
+		Status:       domain.DeletionStatusPending,
+		RequestedAt:  now,
+		ScheduledFor: now,
+	}
+
+	counts, err := s.deleteAccount(userID)
+	if err != nil {
+		deletion.LastError = err.Error()
+		s.deletions.Save(deletion)
+		s.record(userID, domain.AuditAccountDeletionFailed, counts, err)
+		return err
+	}
+
+	deletion.Status = domain.DeletionStatusCompleted
+	deletion.CompletedAt = &now
+	if err := s.deletions.Save(deletion); err != nil {
+		return fmt.Errorf("failed to record deletion: %w", err)
+	}
+	s.record(userID, domain.AuditAccountDeleted, counts, nil)
+	return nil
+}
+
+// DeleteDueAccounts erases the accounts whose grace period has passed. An
+// account that fails part way stays pending and is picked up on the next run.
+func (s *AccountServiceImpl) DeleteDueAccounts() (int, error) {
+	due, err := s.deletions.ListDue(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due deletions: %w", err)
+	}
+
+	deleted := 0
+	var errs []error
+	for _, deletion := range due {
+		counts, err := s.deleteAccount(deletion.UserID)
+		if err != nil {
+			deletion.LastError = err.Error()
+			s.deletions.Save(deletion)
+			s.record(deletion.UserID, domain.AuditAccountDeletionFailed, counts, err)
+			errs = append(errs, fmt.Errorf("user %s: %w", deletion.UserID, err))
+			continue
+		}
+
+		now := time.Now()
+		deletion.Status = domain.DeletionStatusCompleted
+		deletion.CompletedAt = &now
+		deletion.LastError = ""
+		s.deletions.Save(deletion)
+		s.record(deletion.UserID, domain.AuditAccountDeleted, counts, nil)
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// deleteAccount erases a user and everything that belongs to them, counting
+// what was removed. Each step only touches what's left, so it can be rerun.
+func (s *AccountServiceImpl) deleteAccount(userID string) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return counts, err
+	}
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return counts, fmt.Errorf("failed to get wardrobe items: %w", err)
+	}
+	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, domain.OutfitQuery{})
+	if err != nil {
+		return counts, fmt.Errorf("failed to get outfits: %w", err)
+	}
+
+	// Images go first: while they remain, so do the records that point at them,
+	// and a failed run can find them again
+	for _, imageURL := range imageURLs(user, items, outfits) {
+		// Another user's image is theirs to delete, even if this account
+		// referenced it
+		err := s.images.Delete(userID, imageURL)
+		if errors.Is(err, domain.ErrImageNotStored) || errors.Is(err, domain.ErrImageNotOwned) {
+			continue
+		}
+		if err != nil {
+			return counts, fmt.Errorf("failed to delete image: %w", err)
+		}
+		counts["images"]++
+	}
+
+	reflections, err := s.outfitRepo.GetReflectionsByUserID(userID)
+	if err != nil {
+		return counts, fmt.Errorf("failed to get reflections: %w", err)
+	}
+	if err := s.outfitRepo.DeleteReflectionsByUserID(userID); err != nil {
+		return counts, fmt.Errorf("failed to delete reflections: %w", err)
+	}
+	counts["reflections"] = len(reflections)
+
+	for _, outfit := range outfits {
+		if err := s.outfitRepo.DeleteOutfit(outfit.ID); err != nil {
+			return counts, fmt.Errorf("failed to delete outfit %s: %w", outfit.ID, err)
+		}
+		counts["outfits"]++
+	}
+	for _, item := range items {
+		if err := s.wardrobeRepo.DeleteItem(item.ID); err != nil {
+			return counts, fmt.Errorf("failed to delete item %s: %w", item.ID, err)
+		}
+		counts["items"]++
+	}
+
+	categories, err := s.wardrobeRepo.GetUserCategories(userID)
+	if err != nil {
+		return counts, fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, category := range categories {
+		if err := s.wardrobeRepo.DeleteUserCategory(userID, category.ID); err != nil {
+			return counts, fmt.Errorf("failed to delete category %s: %w", category.ID, err)
+		}
+		counts["categories"]++
+	}
+
+	recommendations, err := s.recommendationRepo.GetRecommendationsByUserID(userID)
+	if err != nil {
+		return counts, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	if err := s.recommendationRepo.DeleteRecommendationsByUserID(userID); err != nil {
+		return counts, fmt.Errorf("failed to delete recommendations: %w", err)
+	}
+	counts["recommendations"] = len(recommendations)
+
+	// The profile and style profile go last, once nothing refers to them
+	if user != nil {
+		if err := s.userRepo.Delete(userID); err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+			return counts, fmt.Errorf("failed to delete user: %w", err)
+		}
+	}
+
+	// Sync history and stored responses hold copies of the user's data too
+	s.changes.Purge(userID)
+	if err := s.idempotency.Purge(userID); err != nil {
+		return counts, fmt.Errorf("failed to purge idempotency records: %w", err)
+	}
+	if counts["apiKeys"], err = s.apiKeys.DeleteByUserID(userID); err != nil {
+		return counts, fmt.Errorf("failed to delete API keys: %w", err)
+	}
+	if counts["sessions"], err = s.sessions.DeleteByUserID(userID); err != nil {
+		return counts, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return counts, nil
+}
+
+// record appends to the audit log. A failure to audit doesn't undo the action.
+func (s *AccountServiceImpl) record(userID, action string, details map[string]int, err error) {
+	entry := &domain.AuditEntry{
+		UserID:  userID,
+		Action:  action,
+		Details: details,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.audit.Append(entry)
+}
+
+// imageURLs lists the distinct images referenced by a user's profile, items
+// and outfits
+func imageURLs(user *domain.User, items []*domain.ClothingItem, outfits []*domain.Outfit) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if user != nil {
+		add(user.Picture)
+	}
+	for _, item := range items {
+		for _, u := range item.ImageURLs {
+			add(u)
+		}
+	}
+	for _, outfit := range outfits {
+		add(outfit.ImageURL)
+	}
+	return urls
+}
+
+// imageExtension picks a file extension for an exported image from its URL,
+// or failing that its content type
+func imageExtension(imageURL, contentType string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if ext := path.Ext(u.Path); ext != "" {
+			return ext
+		}
+	}
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AdminServiceImpl implements AdminService
+type AdminServiceImpl struct {
+	userRepo        domain.UserRepository
+	wardrobeRepo    domain.WardrobeRepository
+	wardrobeService domain.WardrobeService
+	audit           domain.AuditLog
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(
+	userRepo domain.UserRepository,
+	wardrobeRepo domain.WardrobeRepository,
+	wardrobeService domain.WardrobeService,
+	audit domain.AuditLog,
+) domain.AdminService {
+	return &AdminServiceImpl{
+		userRepo:        userRepo,
+		wardrobeRepo:    wardrobeRepo,
+		wardrobeService: wardrobeService,
+		audit:           audit,
+	}
+}
+
+// ListUsers retrieves one page of the users matching a query
+func (s *AdminServiceImpl) ListUsers(query domain.UserQuery, page domain.PageRequest) ([]*domain.User, *domain.PageInfo, error) {
+	if query.Role != "" {
+		if _, ok := domain.ParseRole(string(query.Role)); !ok {
+			return nil, nil, fmt.Errorf("invalid role: %s", query.Role)
+		}
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.userRepo.ListUsers(query, page)
+}
+
+// GetUser retrieves any user by ID
+func (s *AdminServiceImpl) GetUser(id string) (*domain.User, error) {
+	return s.userRepo.GetByID(id)
+}
+
+// GetUserItems retrieves one page of a user's wardrobe, for support
+func (s *AdminServiceImpl) GetUserItems(userID string, query domain.WardrobeQuery, page domain.PageRequest) ([]*domain.ClothingItem, *domain.PageInfo, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, nil, err
+	}
+	return s.wardrobeService.GetUserItems(userID, query, page)
+}
+
+// SetUserDisabled disables or re-enables an account. Disabled users are turned
+// away at sign-in but keep their data.
+func (s *AdminServiceImpl) SetUserDisabled(actorID, userID string, disabled bool) (*domain.User, error) {
+	if disabled && actorID == userID {
+		return nil, domain.ErrDisableSelf
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled() == disabled {
+		return user, nil
+	}
+
+	// Only DisabledAt is written, so a profile update racing this one can't
+	// undo it
+	action := domain.AuditUserEnabled
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+		action = domain.AuditUserDisabled
+	}
+	updated, err := s.userRepo.SetDisabledAt(userID, disabledAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// A failure to audit doesn't undo the action
+	s.audit.Append(&domain.AuditEntry{
+		UserID:  userID,
+		ActorID: actorID,
+		Action:  action,
+	})
+	return updated, nil
+}
+
+// ListCategories retrieves the built-in categories shared by every user
+func (s *AdminServiceImpl) ListCategories() ([]*domain.ClothingCategory, error) {
+	return s.wardrobeRepo.GetCategories()
+}
+
+// CreateCategory adds a built-in category for every user
+func (s *AdminServiceImpl) CreateCategory(name string, subcategories []string) (*domain.ClothingCategory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("category name is required")
+	}
+	id := categorySlug(name)
+	if id == "" {
+		return nil, errors.New("category name must contain letters or digits")
+	}
+
+	categories, err := s.wardrobeRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	if findCategory(categories, id) != nil || findCategory(categories, name) != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCategoryExists, name)
+	}
+
+	category := &domain.ClothingCategory{
+		ID:            id,
+		Name:          name,
+		Subcategories: cleanSubcategories(subcategories),
+	}
+	if err := s.wardrobeRepo.SaveCategory(category); err != nil {
+		return nil, fmt.Errorf("failed to save category: %w", err)
+	}
+	return category, nil
+}
+
+// UpdateCategory renames a built-in category and replaces its subcategories.
+// Its ID doesn't change, so items filed under it stay there, but a subcategory
+// can only be dropped once no item uses it.
+func (s *AdminServiceImpl) UpdateCategory(id, name string, subcategories []string) (*domain.ClothingCategory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("category name is required")
+	}
+
+	categories, err := s.wardrobeRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	var existing *domain.ClothingCategory
+	for _, category := range categories {
+		if category.ID == id {
+			existing = category
+		} else if strings.EqualFold(category.Name, name) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCategoryExists, name)
+		}
+	}
+	if existing == nil {
+		return nil, domain.ErrCategoryNotFound
+	}
+
+	category := &domain.ClothingCategory{
+		ID:            existing.ID,
+		Name:          name,
+		Subcategories: cleanSubcategories(subcategories),
+	}
+	if err := s.checkCategoryUnused(existing.ID, category); err != nil {
+		return nil, err
+	}
+	if err := s.wardrobeRepo.SaveCategory(category); err != nil {
+		return nil, fmt.Errorf("failed to save category: %w", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory removes a built-in category no item is filed under
+func (s *AdminServiceImpl) DeleteCategory(id string) error {
+	if err := s.checkCategoryUnused(id, nil); err != nil {
+		return err
+	}
+	return s.wardrobeRepo.DeleteCategory(id)
+}
+
+// checkCategoryUnused returns ErrCategoryInUse if an item is filed under the
+// built-in category with a subcategory that replacement, or the owner's own
+// additions to it, wouldn't keep. A nil replacement means the category is
+// going, so any item filed under it is in the way. Items saved while the check
+// runs aren't seen; they're rejected again on their next update.
+func (s *AdminServiceImpl) checkCategoryUnused(id string, replacement *domain.ClothingCategory) error {
+	query := domain.WardrobeQuery{Category: domain.StringFilter{Include: []string{id}}}
+	page := domain.PageRequest{Limit: domain.MaxPageLimit, SortBy: domain.SortByCreatedAt}
+	for {
+		users, pageInfo, err := s.userRepo.ListUsers(domain.UserQuery{}, page)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			items, err := s.wardrobeRepo.GetItemsByUserID(user.ID, query)
+			if err != nil {
+				return fmt.Errorf("failed to get wardrobe items: %w", err)
+			}
+			if len(items) == 0 {
+				continue
+			}
+			if replacement == nil {
+				return fmt.Errorf("%w: %d items are filed under it", domain.ErrCategoryInUse, len(items))
+			}
+
+			// The user's own subcategories of the category stay
+			kept := &domain.ClothingCategory{Subcategories: append([]string{}, replacement.Subcategories...)}
+			personal, err := s.wardrobeRepo.GetUserCategories(user.ID)
+			if err != nil {
+				return err
+			}
+			for _, category := range personal {
+				if category.ID == id && !category.IsCustom {
+					kept.Subcategories = append(kept.Subcategories, category.Subcategories...)
+				}
+			}
+			for _, item := range items {
+				if item.Subcategory != "" && findSubcategory(kept, item.Subcategory) == "" {
+					return fmt.Errorf("%w: items are filed under subcategory %s", domain.ErrCategoryInUse, item.Subcategory)
+				}
+			}
+		}
+		if !pageInfo.HasMore {
+			return nil
+		}
+		page.Cursor = pageInfo.NextCursor
+	}
+}
+
+// cleanSubcategories trims subcategory names and drops blanks and duplicates
+func cleanSubcategories(names []string) []string {
+	category := &domain.ClothingCategory{Subcategories: []string{}}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && findSubcategory(category, name) == "" {
+			category.Subcategories = append(category.Subcategories, name)
+		}
+	}
+	return category.Subcategories
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// DefaultUnwornDays is the window used to flag unworn items when none is given
+const DefaultUnwornDays = 90
+
+// AnalyticsServiceImpl implements AnalyticsService
+type AnalyticsServiceImpl struct {
+	wardrobeRepo domain.WardrobeRepository
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(wardrobeRepo domain.WardrobeRepository) domain.AnalyticsService {
+	return &AnalyticsServiceImpl{
+		wardrobeRepo: wardrobeRepo,
+	}
+}
+
+// GetWardrobeAnalytics computes cost-per-wear, utilization and distribution figures
+// for a user's owned items. Wears come from the counts recorded on each item as
+// outfits are reflected on, and items that were donated or sold are reported
+// separately with their history intact.
+func (s *AnalyticsServiceImpl) GetWardrobeAnalytics(userID string, unwornDays int) (*domain.WardrobeAnalytics, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if unwornDays <= 0 {
+		unwornDays = DefaultUnwornDays
+	}
+
+	owned := true
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{IsOwned: &owned})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user wardrobe: %w", err)
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -unwornDays)
+
+	analytics := &domain.WardrobeAnalytics{
+		TotalValue:           make(map[string]float64),
+		UnwornDays:           unwornDays,
+		Items:                []*domain.ItemUsage{},
+		UnwornItems:          []*domain.ItemUsage{},
+		DepartedItems:        []*domain.ItemUsage{},
+		StatusCounts:         make(map[domain.ItemStatus]int),
+		CategoryUtilization:  []*domain.CategoryUtilization{},
+		CategoryDistribution: make(map[string]int),
+		ColorDistribution:    make(map[string]int),
+	}
+
+	utilization := make(map[string]*domain.CategoryUtilization)
+	for _, item := range items {
+		usage := &domain.ItemUsage{
+			ItemID:        item.ID,
+			Name:          item.Name,
+			Category:      item.Category,
+			Color:         item.Color,
+			Status:        item.CurrentStatus(),
+			WearCount:     item.WearCount,
+			LastWornAt:    item.LastWornAt,
+			PurchasePrice: item.PurchasePrice,
+			Currency:      item.Currency,
+		}
+
+		if item.PurchasePrice > 0 {
+			// An unworn item has cost its full price for zero wears so far
+			wearsSoFar := math.Max(float64(usage.WearCount), 1)
+			costPerWear := math.Round(item.PurchasePrice/wearsSoFar*100) / 100
+			usage.CostPerWear = &costPerWear
+		}
+
+		analytics.StatusCounts[usage.Status]++
+
+		// Items that left the wardrobe keep their wear history but don't
+		// count towards what the user currently owns
+		if !item.InWardrobe() {
+			usage.LeftAt = leftWardrobeAt(item)
+			analytics.DepartedItems = append(analytics.DepartedItems, usage)
+			continue
+		}
+
+		analytics.TotalItems++
+		analytics.Items = append(analytics.Items, usage)
+		if item.PurchasePrice > 0 {
+			analytics.TotalValue[item.Currency] += item.PurchasePrice
+		}
+
+		category := normalizeCategory(item.Category)
+		analytics.CategoryDistribution[category]++
+		analytics.ColorDistribution[strings.ToLower(strings.TrimSpace(item.Color))]++
+
+		cu, ok := utilization[category]
+		if !ok {
+			cu = &domain.CategoryUtilization{Category: category}
+			utilization[category] = cu
+		}
+		cu.TotalItems++
+
+		wornRecently := usage.LastWornAt != nil && !usage.LastWornAt.Before(cutoff)
+		if wornRecently {
+			cu.WornItems++
+		} else if ownedSince(item).Before(cutoff) {
+			// Items acquired inside the window haven't had a chance to go unworn
+			analytics.UnwornItems = append(analytics.UnwornItems, usage)
+		}
+	}
+
+	for _, cu := range utilization {
+		cu.UtilizationPercent = math.Round(float64(cu.WornItems)/float64(cu.TotalItems)*1000) / 10
+		analytics.CategoryUtilization = append(analytics.CategoryUtilization, cu)
+	}
+
+	sort.Slice(analytics.Items, func(i, j int) bool {
+		return costPerWearOf(analytics.Items[i]) > costPerWearOf(analytics.Items[j])
+	})
+	sort.Slice(analytics.UnwornItems, func(i, j int) bool {
+		return analytics.UnwornItems[i].Name < analytics.UnwornItems[j].Name
+	})
+	sort.Slice(analytics.DepartedItems, func(i, j int) bool {
+		return analytics.DepartedItems[i].Name < analytics.DepartedItems[j].Name
+	})
+	sort.Slice(analytics.CategoryUtilization, func(i, j int) bool {
+		return analytics.CategoryUtilization[i].Category < analytics.CategoryUtilization[j].Category
+	})
+
+	return analytics, nil
+}
+
+// ownedSince returns when the user acquired an item, falling back to when it was added
+func ownedSince(item *domain.ClothingItem) time.Time {
+	if item.PurchaseDate != nil {
+		return *item.PurchaseDate
+	}
+	return item.CreatedAt
+}
+
+// leftWardrobeAt returns when an item was donated or sold, if recorded
+func leftWardrobeAt(item *domain.ClothingItem) *time.Time {
+	for i := len(item.StatusHistory) - 1; i >= 0; i-- {
+		t := item.StatusHistory[i]
+		if t.To == domain.ItemStatusDonated || t.To == domain.ItemStatusSold {
+			at := t.At
+			return &at
+		}
+	}
+	return nil
+}
+
+func costPerWearOf(usage *domain.ItemUsage) float64 {
+	if usage.CostPerWear == nil {
+		return -1
+	}
+	return *usage.CostPerWear
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// AuthWebhookServiceImpl implements the AuthWebhookService interface
+type AuthWebhookServiceImpl struct {
+	userService    domain.UserService
+	accountService domain.AccountService
+	replayWindow   time.Duration
+}
+
+// NewAuthWebhookService creates a new AuthWebhookService. replayWindow is how
+// long after a deletion its identity's other events may still be delivered.
+func NewAuthWebhookService(userService domain.UserService, accountService domain.AccountService, replayWindow time.Duration) domain.AuthWebhookService {
+	return &AuthWebhookServiceImpl{
+		userService:    userService,
+		accountService: accountService,
+		replayWindow:   replayWindow,
+	}
+}
+
+// HandleAuthEvent applies a change made in the identity provider. Created and
+// updated users are provisioned the same way as on sign-in, so whichever
+// arrives first creates the user. Deleted users have their data erased, and
+// their identity is blocked for the replay window so events for it that
+// arrive late are ignored, even if the user was never provisioned here.
+func (s *AuthWebhookServiceImpl) HandleAuthEvent(event *domain.AuthEvent) error {
+	if event.Identity == nil || event.Identity.SupabaseID == "" {
+		return errors.New("auth event has no user")
+	}
+
+	switch event.Type {
+	case domain.AuthEventUserCreated, domain.AuthEventUserUpdated:
+		_, err := s.userService.ProvisionSupabaseUser(event.Identity)
+		if errors.Is(err, domain.ErrUserDeleted) {
+			return nil
+		}
+		return err
+
+	case domain.AuthEventUserDeleted:
+		// Block the identity first, so a created or updated event for it
+		// can't provision it while the account is erased
+		until := time.Now().Add(s.replayWindow)
+		if err := s.userService.BlockSupabaseUser(event.Identity.SupabaseID, until); err != nil {
+			return fmt.Errorf("failed to block user: %w", err)
+		}
+
+		// Events may be redelivered; a user we never saw, or already
+		// deleted, has nothing left to erase
+		user, err := s.userService.GetUserBySupabaseID(event.Identity.SupabaseID)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return s.accountService.DeleteAccount(user.ID)
+	}
+	return fmt.Errorf("unsupported auth event type %q", event.Type)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/internal/repository"
+)
+
+// deletingAccountService erases accounts by deleting the user and nothing else
+type deletingAccountService struct {
+	domain.AccountService
+	users domain.UserRepository
+}
+
+func (s *deletingAccountService) DeleteAccount(userID string) error {
+	return s.users.Delete(userID)
+}
+
+func TestHandleAuthEvent(t *testing.T) {
+	identity := func() *domain.User {
+		return &domain.User{SupabaseID: "supabase-1", Email: "user@example.com"}
+	}
+
+	tests := []struct {
+		name       string
+		events     []string
+		wantExists bool
+	}{
+		{name: "created", events: []string{domain.AuthEventUserCreated}, wantExists: true},
+		{name: "updated before created", events: []string{domain.AuthEventUserUpdated, domain.AuthEventUserCreated}, wantExists: true},
+		{name: "deleted", events: []string{domain.AuthEventUserCreated, domain.AuthEventUserDeleted}},
+		{name: "created after deleted", events: []string{domain.AuthEventUserCreated, domain.AuthEventUserDeleted, domain.AuthEventUserCreated}},
+		{name: "deleted before ever being provisioned", events: []string{domain.AuthEventUserDeleted, domain.AuthEventUserUpdated}},
+		{name: "deletion redelivered", events: []string{domain.AuthEventUserCreated, domain.AuthEventUserDeleted, domain.AuthEventUserDeleted}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := repository.NewUserRepository()
+			service := NewAuthWebhookService(NewUserService(users), &deletingAccountService{users: users}, time.Hour)
+
+			for _, eventType := range tt.events {
+				if err := service.HandleAuthEvent(&domain.AuthEvent{Type: eventType, Identity: identity()}); err != nil {
+					t.Fatalf("HandleAuthEvent(%s): %v", eventType, err)
+				}
+			}
+
+			_, err := users.GetBySupabaseID("supabase-1")
+			if exists := err == nil; exists != tt.wantExists {
+				t.Errorf("user exists = %v, want %v", exists, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestHandleAuthEventBlockExpires(t *testing.T) {
+	users := repository.NewUserRepository()
+	service := NewAuthWebhookService(NewUserService(users), &deletingAccountService{users: users}, time.Millisecond)
+
+	if err := service.HandleAuthEvent(&domain.AuthEvent{Type: domain.AuthEventUserDeleted, Identity: &domain.User{SupabaseID: "supabase-1"}}); err != nil {
+		t.Fatalf("HandleAuthEvent: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Once the replay window has passed the identity can sign up again
+	if _, err := NewUserService(users).ProvisionSupabaseUser(&domain.User{SupabaseID: "supabase-1", Email: "user@example.com"}); err != nil {
+		t.Errorf("ProvisionSupabaseUser: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// ExportServiceImpl implements ExportService
+type ExportServiceImpl struct {
+	wardrobeService domain.WardrobeService
+	outfitService   domain.OutfitService
+}
+
+// NewExportService creates a new export service
+func NewExportService(wardrobeService domain.WardrobeService, outfitService domain.OutfitService) domain.ExportService {
+	return &ExportServiceImpl{
+		wardrobeService: wardrobeService,
+		outfitService:   outfitService,
+	}
+}
+
+// Export collects all of a user's items, outfits and reflections, oldest first
+func (s *ExportServiceImpl) Export(userID string) (*domain.WardrobeExport, error) {
+	export := &domain.WardrobeExport{
+		ExportedAt:  time.Now(),
+		Items:       []*domain.ClothingItem{},
+		Outfits:     []*domain.Outfit{},
+		Reflections: []*domain.Reflection{},
+	}
+
+	page := domain.PageRequest{Limit: domain.MaxPageLimit, SortBy: domain.SortByCreatedAt}
+	for {
+		items, pageInfo, err := s.wardrobeService.GetUserItems(userID, domain.WardrobeQuery{}, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wardrobe items: %w", err)
+		}
+		export.Items = append(export.Items, items...)
+		if !pageInfo.HasMore {
+			break
+		}
+		page.Cursor = pageInfo.NextCursor
+	}
+
+	page.Cursor = ""
+	for {
+		outfits, pageInfo, err := s.outfitService.GetUserOutfits(userID, domain.OutfitQuery{}, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get outfits: %w", err)
+		}
+		export.Outfits = append(export.Outfits, outfits...)
+		if !pageInfo.HasMore {
+			break
+		}
+		page.Cursor = pageInfo.NextCursor
+	}
+
+	reflections, err := s.outfitService.GetUserReflections(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reflections: %w", err)
+	}
+	export.Reflections = append(export.Reflections, reflections...)
+	return export, nil
+}
+
+// WriteExport writes a user's export in the given format
+func (s *ExportServiceImpl) WriteExport(userID, format string, w io.Writer) error {
+	if format != domain.FormatJSON && format != domain.FormatCSV {
+		return domain.ErrUnsupportedFormat
+	}
+	export, err := s.Export(userID)
+	if err != nil {
+		return err
+	}
+
+	if format == domain.FormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	}
+
+	// Spreadsheets read category names, not IDs; the import accepts either
+	categories, err := s.wardrobeService.GetCategories(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeCSVFile(archive, "items.csv", itemCSVRows(export.Items, categoryNames)); err != nil {
+		return err
+	}
+	if err := writeCSVFile(archive, "outfits.csv", outfitCSVRows(export.Outfits)); err != nil {
+		return err
+	}
+	if err := writeCSVFile(archive, "reflections.csv", reflectionCSVRows(export.Reflections)); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeCSVFile(archive *zip.Writer, name string, rows [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// itemCSVRows lays items out with the same column names the import reads, so
+// an exported file can be imported again
+func itemCSVRows(items []*domain.ClothingItem, categoryNames map[string]string) [][]string {
+	header := append([]string{"id"}, importFields...)
+	header = append(header, "wearCount", "lastWornAt", "createdAt", "updatedAt")
+	rows := [][]string{header}
+
+	for _, item := range items {
+		category := item.Category
+		if name, ok := categoryNames[category]; ok {
+			category = name
+		}
+		purchaseDate := ""
+		if item.PurchaseDate != nil {
+			purchaseDate = item.PurchaseDate.Format("2006-01-02")
+		}
+		purchasePrice := ""
+		if item.PurchasePrice != 0 {
+			purchasePrice = strconv.FormatFloat(item.PurchasePrice, 'f', 2, 64)
+		}
+
+		rows = append(rows, []string{
+			item.ID,
+			item.Name,
+			category,
+			item.Subcategory,
+			item.Color,
+			strings.Join(item.Season, "; "),
+			item.Brand,
+			item.Size,
+			strings.Join(item.ImageURLs, "; "),
+			strconv.FormatBool(item.IsOwned),
+			item.Material,
+			item.Pattern,
+			item.Fit,
+			formatScale(item.Formality),
+			formatScale(item.Warmth),
+			item.Waterproof,
+			strings.Join(item.CareInstructions, "; "),
+			item.Notes,
+			purchasePrice,
+			item.Currency,
+			purchaseDate,
+			string(item.Status),
+			strconv.Itoa(item.WearCount),
+			formatOptionalTime(item.LastWornAt),
+			item.CreatedAt.Format(time.RFC3339),
+			item.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func outfitCSVRows(outfits []*domain.Outfit) [][]string {
+	rows := [][]string{{
+		"id", "name", "description", "items", "occasion", "season", "isFavorite",
+		"isRecommended", "wearCount", "lastWornAt", "createdAt", "updatedAt",
+	}}
+	for _, outfit := range outfits {
+		rows = append(rows, []string{
+			outfit.ID,
+			outfit.Name,
+			outfit.Description,
+			strings.Join(outfit.Items, "; "),
+			strings.Join(outfit.Occasion, "; "),
+			strings.Join(outfit.Season, "; "),
+			strconv.FormatBool(outfit.IsFavorite),
+			strconv.FormatBool(outfit.IsRecommended),
+			strconv.Itoa(outfit.WearCount),
+			formatOptionalTime(outfit.LastWornAt),
+			outfit.CreatedAt.Format(time.RFC3339),
+			outfit.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func reflectionCSVRows(reflections []*domain.Reflection) [][]string {
+	rows := [][]string{{"id", "outfitId", "date", "confidence", "comfort", "wouldRewear", "notes", "createdAt"}}
+	for _, reflection := range reflections {
+		rows = append(rows, []string{
+			reflection.ID,
+			reflection.OutfitID,
+			reflection.Date.Format(time.RFC3339),
+			strconv.Itoa(reflection.Confidence),
+			strconv.Itoa(reflection.Comfort),
+			strconv.FormatBool(reflection.WouldRewear),
+			reflection.Notes,
+			reflection.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+// formatScale leaves unset 1-5 attributes blank rather than writing 0
+func formatScale(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// Occasions the composer understands. Weekly schedule entries and item
+// subcategories are mapped onto these.
+const (
+	occasionCasual   = "casual"
+	occasionWork     = "work"
+	occasionFormal   = "formal"
+	occasionAthletic = "athletic"
+)
+
+var allOccasions = []string{occasionCasual, occasionWork, occasionFormal, occasionAthletic}
+
+// outfitTemplates lists the category combinations that make a complete base outfit.
+// Outerwear is treated as an optional layer on top of any base outfit.
+var outfitTemplates = [][]string{
+	{"tops", "bottoms", "shoes"},
+	{"dresses", "shoes"},
+}
+
+const layerCategory = "outerwear"
+
+// neutralColors pair with every other color
+var neutralColors = map[string]bool{
+	"black": true,
+	"white": true,
+	"grey":  true,
+	"gray":  true,
+	"navy":  true,
+	"beige": true,
+	"brown": true,
+	"tan":   true,
+	"cream": true,
+	"denim": true,
+}
+
+// subcategoryOccasions maps built-in subcategories to the occasions they suit.
+// Anything not listed is considered casual.
+var subcategoryOccasions = map[string][]string{
+	"t-shirts":       {occasionCasual, occasionAthletic},
+	"shirts":         {occasionCasual, occasionWork, occasionFormal},
+	"blouses":        {occasionCasual, occasionWork},
+	"sweaters":       {occasionCasual, occasionWork},
+	"hoodies":        {occasionCasual, occasionAthletic},
+	"tank tops":      {occasionCasual, occasionAthletic},
+	"jeans":          {occasionCasual},
+	"pants":          {occasionCasual, occasionWork, occasionFormal},
+	"shorts":         {occasionCasual, occasionAthletic},
+	"skirts":         {occasionCasual, occasionWork},
+	"leggings":       {occasionCasual, occasionAthletic},
+	"casual dresses": {occasionCasual},
+	"formal dresses": {occasionFormal},
+	"maxi dresses":   {occasionCasual, occasionFormal},
+	"mini dresses":   {occasionCasual},
+	"jackets":        {occasionCasual},
+	"coats":          {occasionCasual, occasionWork, occasionFormal},
+	"blazers":        {occasionWork, occasionFormal},
+	"cardigans":      {occasionCasual, occasionWork},
+	"vests":          {occasionCasual, occasionWork},
+	"sneakers":       {occasionCasual, occasionAthletic},
+	"boots":          {occasionCasual, occasionWork},
+	"heels":          {occasionWork, occasionFormal},
+	"flats":          {occasionCasual, occasionWork},
+	"sandals":        {occasionCasual},
+	"athletic shoes": {occasionAthletic},
+}
+
+// normalizeCategory returns the lower-cased category ID for an item category
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// formalityRanges gives the formality levels that suit each occasion
+var formalityRanges = map[string][2]int{
+	occasionAthletic: {1, 2},
+	occasionCasual:   {1, 3},
+	occasionWork:     {3, 4},
+	occasionFormal:   {4, 5},
+}
+
+// itemSuitsOccasion reports whether an item can be worn for the given occasion.
+// An explicit formality level takes precedence over the subcategory defaults.
+func itemSuitsOccasion(item *domain.ClothingItem, occasion string) bool {
+	if item.Formality != 0 {
+		r, ok := formalityRanges[occasion]
+		return ok && item.Formality >= r[0] && item.Formality <= r[1]
+	}
+
+	occasions, ok := subcategoryOccasions[strings.ToLower(strings.TrimSpace(item.Subcategory))]
+	if !ok {
+		return occasion == occasionCasual
+	}
+	for _, o := range occasions {
+		if o == occasion {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleOccasion maps a weekly schedule entry onto a composer occasion
+func scheduleOccasion(dayType string) string {
+	switch strings.ToLower(strings.TrimSpace(dayType)) {
+	case "professional", "work", "business", "office", "business casual":
+		return occasionWork
+	case "formal", "evening", "event":
+		return occasionFormal
+	case "athletic", "active", "gym", "sport", "sports":
+		return occasionAthletic
+	default:
+		return occasionCasual
+	}
+}
+
+// scheduleWeights counts how many days of the week call for each occasion.
+// Without a schedule every occasion is weighted equally.
+func scheduleWeights(profile *domain.StyleProfile) map[string]int {
+	weights := make(map[string]int)
+	if profile == nil {
+		for _, occasion := range allOccasions {
+			weights[occasion] = 1
+		}
+		return weights
+	}
+
+	schedule := profile.WeeklySchedule
+	for _, day := range []string{
+		schedule.Monday, schedule.Tuesday, schedule.Wednesday, schedule.Thursday,
+		schedule.Friday, schedule.Saturday, schedule.Sunday,
+	} {
+		if day == "" {
+			continue
+		}
+		weights[scheduleOccasion(day)]++
+	}
+
+	if len(weights) == 0 {
+		for _, occasion := range allOccasions {
+			weights[occasion] = 1
+		}
+	}
+	return weights
+}
+
+// colorsCompatible reports whether two colors can be worn together.
+// Neutrals go with anything; two accent colors only pair if they match.
+func colorsCompatible(a, b string) bool {
+	a = strings.ToLower(strings.TrimSpace(a))
+	b = strings.ToLower(strings.TrimSpace(b))
+	return neutralColors[a] || neutralColors[b] || a == b
+}
+
+// formalityCompatible reports whether two items are close enough in formality to
+// be worn together. Items without a formality level pair with anything.
+func formalityCompatible(a, b *domain.ClothingItem) bool {
+	if a.Formality == 0 || b.Formality == 0 {
+		return true
+	}
+	diff := a.Formality - b.Formality
+	return diff >= -2 && diff <= 2
+}
+
+// fitsWith reports whether an item is color and formality compatible with every item already chosen
+func fitsWith(item *domain.ClothingItem, chosen []*domain.ClothingItem) bool {
+	for _, c := range chosen {
+		if !colorsCompatible(item.Color, c.Color) || !formalityCompatible(item, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// itemSuitsSeason reports whether an item's warmth rating suits a season.
+// Items without a warmth rating suit every season.
+func itemSuitsSeason(item *domain.ClothingItem, season string) bool {
+	if item.Warmth == 0 {
+		return true
+	}
+	switch season {
+	case "Summer":
+		return item.Warmth <= 3
+	case "Winter":
+		return item.Warmth >= 2
+	default:
+		return true
+	}
+}
+
+// currentSeason returns the (northern hemisphere) season for a date
+func currentSeason(t time.Time) string {
+	switch t.Month() {
+	case time.December, time.January, time.February:
+		return "Winter"
+	case time.March, time.April, time.May:
+		return "Spring"
+	case time.June, time.July, time.August:
+		return "Summer"
+	default:
+		return "Fall"
+	}
+}
+
+// groupByCategory groups items into pools keyed by normalized category
+func groupByCategory(items []*domain.ClothingItem) map[string][]*domain.ClothingItem {
+	pools := make(map[string][]*domain.ClothingItem)
+	for _, item := range items {
+		category := normalizeCategory(item.Category)
+		pools[category] = append(pools[category], item)
+	}
+	return pools
+}
+
+// itemGroup stands for count items that suit an occasion and share a color
+// class and formality level. Compatibility only depends on those, so outfits
+// can be counted per group rather than per item.
+type itemGroup struct {
+	item  *domain.ClothingItem // any one of the items, for compatibility checks
+	count int
+}
+
+// groupPool groups the items of a pool that suit an occasion
+func groupPool(pool []*domain.ClothingItem, occasion string) []itemGroup {
+	var groups []itemGroup
+	index := make(map[string]int)
+	for _, item := range pool {
+		if !itemSuitsOccasion(item, occasion) {
+			continue
+		}
+		color := strings.ToLower(strings.TrimSpace(item.Color))
+		if neutralColors[color] {
+			color = "neutral"
+		}
+		key := color + "/" + strconv.Itoa(item.Formality)
+		if i, ok := index[key]; ok {
+			groups[i].count++
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, itemGroup{item: item, count: 1})
+	}
+	return groups
+}
+
+// countBaseOutfits counts the base outfits for an occasion that can be built
+// from pools and that every item in with is compatible with. When fixed is
+// set, only outfits that include it are counted. With layered set, outfits
+// are counted with one of the pool's layers on top instead.
+func countBaseOutfits(pools map[string][]*domain.ClothingItem, occasion string, fixed *domain.ClothingItem, with []*domain.ClothingItem, layered bool) int {
+	total := 0
+	for _, template := range outfitTemplates {
+		if fixed != nil && !templateHasCategory(template, normalizeCategory(fixed.Category)) {
+			continue
+		}
+
+		slots := make([][]itemGroup, 0, len(template)+1)
+		for _, category := range template {
+			if fixed != nil && normalizeCategory(fixed.Category) == category {
+				slots = append(slots, []itemGroup{{item: fixed, count: 1}})
+				continue
+			}
+			slots = append(slots, groupPool(pools[category], occasion))
+		}
+		if layered {
+			slots = append(slots, groupPool(pools[layerCategory], occasion))
+		}
+		total += countCombinations(slots, with)
+	}
+	return total
+}
+
+func templateHasCategory(template []string, category string) bool {
+	for _, c := range template {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// countCombinations counts the ways to pick one item per slot so that every
+// pick is compatible with the others and with chosen. Each compatible group
+// contributes its size times the ways to fill the remaining slots, so the work
+// grows with the number of groups per slot rather than the number of items.
+func countCombinations(slots [][]itemGroup, chosen []*domain.ClothingItem) int {
+	if len(slots) == 0 {
+		return 1
+	}
+
+	total := 0
+	for _, group := range slots[0] {
+		if !fitsWith(group.item, chosen) {
+			continue
+		}
+		total += group.count * countCombinations(slots[1:], append(chosen, group.item))
+	}
+	return total
+}
+
+// countOutfitsWith counts the outfits for an occasion that become possible
+// once candidate is added to the wardrobe, including layered outfits.
+func countOutfitsWith(pools map[string][]*domain.ClothingItem, candidate *domain.ClothingItem, occasion string) int {
+	if !itemSuitsOccasion(candidate, occasion) {
+		return 0
+	}
+
+	if normalizeCategory(candidate.Category) == layerCategory {
+		// A new layer creates one new outfit per compatible base outfit
+		return countBaseOutfits(pools, occasion, nil, []*domain.ClothingItem{candidate}, false)
+	}
+
+	// New base outfits, plus each of those worn with an existing layer
+	return countBaseOutfits(pools, occasion, candidate, nil, false) +
+		countBaseOutfits(pools, occasion, candidate, nil, true)
+}
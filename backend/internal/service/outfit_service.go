@@ -3,19 +3,22 @@ package service
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/lilo/backend/internal/domain"
 )
 
 // OutfitServiceImpl implements OutfitService
 type OutfitServiceImpl struct {
-	outfitRepo domain.OutfitRepository
+	outfitRepo   domain.OutfitRepository
+	wardrobeRepo domain.WardrobeRepository
 }
 
 // NewOutfitService creates a new outfit service
-func NewOutfitService(outfitRepo domain.OutfitRepository) domain.OutfitService {
+func NewOutfitService(outfitRepo domain.OutfitRepository, wardrobeRepo domain.WardrobeRepository) domain.OutfitService {
 	return &OutfitServiceImpl{
-		outfitRepo: outfitRepo,
+		outfitRepo:   outfitRepo,
+		wardrobeRepo: wardrobeRepo,
 	}
 }
 
@@ -31,6 +34,9 @@ func (s *OutfitServiceImpl) CreateOutfit(outfit *domain.Outfit) error {
 	if len(outfit.Items) == 0 {
 		return errors.New("outfit must contain at least one item")
 	}
+	if err := s.checkItemsOwned(outfit.UserID, outfit.Items); err != nil {
+		return err
+	}
 
 	// Set default values if not provided
 	if len(outfit.Occasion) == 0 {
@@ -40,6 +46,10 @@ func (s *OutfitServiceImpl) CreateOutfit(outfit *domain.Outfit) error {
 		outfit.Season = []string{"Spring", "Summer", "Fall", "Winter"}
 	}
 
+	// Wear stats are only recorded through reflections
+	outfit.WearCount = 0
+	outfit.LastWornAt = nil
+
 	return s.outfitRepo.CreateOutfit(outfit)
 }
 
@@ -51,12 +61,16 @@ func (s *OutfitServiceImpl) GetOutfit(id string) (*domain.Outfit, error) {
 	return s.outfitRepo.GetOutfitByID(id)
 }
 
-// GetUserOutfits retrieves all outfits for a user with optional filters
-func (s *OutfitServiceImpl) GetUserOutfits(userID string, filters map[string]interface{}) ([]*domain.Outfit, error) {
+// GetUserOutfits retrieves one page of a user's outfits matching a query
+func (s *OutfitServiceImpl) GetUserOutfits(userID string, query domain.OutfitQuery, page domain.PageRequest) ([]*domain.Outfit, *domain.PageInfo, error) {
 	if userID == "" {
-		return nil, errors.New("user ID is required")
+		return nil, nil, errors.New("user ID is required")
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, nil, err
 	}
-	return s.outfitRepo.GetOutfitsByUserID(userID, filters)
+	return s.outfitRepo.ListOutfitsByUserID(userID, query, page)
 }
 
 // UpdateOutfit updates an existing outfit
@@ -73,6 +87,9 @@ func (s *OutfitServiceImpl) UpdateOutfit(outfit *domain.Outfit) error {
 	if len(outfit.Items) == 0 {
 		return errors.New("outfit must contain at least one item")
 	}
+	if err := s.checkItemsOwned(outfit.UserID, outfit.Items); err != nil {
+		return err
+	}
 
 	// Verify outfit exists
 	existingOutfit, err := s.outfitRepo.GetOutfitByID(outfit.ID)
@@ -85,6 +102,17 @@ func (s *OutfitServiceImpl) UpdateOutfit(outfit *domain.Outfit) error {
 		return errors.New("unauthorized: outfit belongs to different user")
 	}
 
+	// A zero version is an unconditional update; any other version must match
+	if outfit.Version == 0 {
+		outfit.Version = existingOutfit.Version
+	} else if outfit.Version != existingOutfit.Version {
+		return domain.ErrVersionConflict
+	}
+
+	outfit.WearCount = existingOutfit.WearCount
+	outfit.LastWornAt = existingOutfit.LastWornAt
+	outfit.CreatedAt = existingOutfit.CreatedAt
+
 	return s.outfitRepo.UpdateOutfit(outfit)
 }
 
@@ -160,7 +188,142 @@ func (s *OutfitServiceImpl) SubmitReflection(reflection *domain.Reflection) erro
 		return errors.New("unauthorized: outfit belongs to different user")
 	}
 
-	return s.outfitRepo.CreateReflection(reflection)
+	// Saving the reflection counts it as a wear of the outfit
+	if err := s.outfitRepo.CreateReflection(reflection); err != nil {
+		return err
+	}
+
+	// It's a wear of each of the user's items in the outfit too; items deleted
+	// since the outfit was saved, or that were never the user's, are skipped
+	wornAt := reflection.Date
+	if wornAt.IsZero() {
+		wornAt = reflection.CreatedAt
+	}
+	for _, itemID := range outfit.Items {
+		item, err := s.wardrobeRepo.GetItemByID(itemID)
+		if errors.Is(err, domain.ErrItemNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get item: %w", err)
+		}
+		if item.UserID != reflection.UserID {
+			continue
+		}
+		err = s.wardrobeRepo.RecordItemWear(itemID, wornAt)
+		if err != nil && !errors.Is(err, domain.ErrItemNotFound) {
+			return fmt.Errorf("failed to record item wear: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkItemsOwned verifies that every item in an outfit exists and belongs to
+// the outfit's user. Another user's items are reported as missing.
+func (s *OutfitServiceImpl) checkItemsOwned(userID string, itemIDs []string) error {
+	for _, itemID := range itemIDs {
+		item, err := s.wardrobeRepo.GetItemByID(itemID)
+		if errors.Is(err, domain.ErrItemNotFound) || err == nil && item.UserID != userID {
+			return fmt.Errorf("%w: %s", domain.ErrItemNotFound, itemID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get item: %w", err)
+		}
+	}
+	return nil
+}
+
+// RebuildWearStats recounts a user's wear stats from their reflections, which
+// are the record of every wear. It backfills stats for reflections logged
+// before they were stored and repairs any that drifted. Run it while nothing
+// else is writing, such as at startup.
+func (s *OutfitServiceImpl) RebuildWearStats(userID string) error {
+	reflections, err := s.outfitRepo.GetReflectionsByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get reflections: %w", err)
+	}
+	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, domain.OutfitQuery{})
+	if err != nil {
+		return fmt.Errorf("failed to get outfits: %w", err)
+	}
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return fmt.Errorf("failed to get items: %w", err)
+	}
+
+	type wearStats struct {
+		count      int
+		lastWornAt *time.Time
+	}
+	add := func(stats map[string]*wearStats, id string, wornAt time.Time) {
+		entry, ok := stats[id]
+		if !ok {
+			entry = &wearStats{}
+			stats[id] = entry
+		}
+		entry.count++
+		if entry.lastWornAt == nil || wornAt.After(*entry.lastWornAt) {
+			entry.lastWornAt = &wornAt
+		}
+	}
+
+	outfitsByID := make(map[string]*domain.Outfit, len(outfits))
+	for _, outfit := range outfits {
+		outfitsByID[outfit.ID] = outfit
+	}
+	outfitStats := make(map[string]*wearStats)
+	itemStats := make(map[string]*wearStats)
+	for _, reflection := range reflections {
+		wornAt := reflection.Date
+		if wornAt.IsZero() {
+			wornAt = reflection.CreatedAt
+		}
+		outfit, ok := outfitsByID[reflection.OutfitID]
+		if !ok {
+			continue
+		}
+		add(outfitStats, outfit.ID, wornAt)
+		for _, itemID := range outfit.Items {
+			add(itemStats, itemID, wornAt)
+		}
+	}
+
+	sameStats := func(count int, lastWornAt *time.Time, stats *wearStats) bool {
+		if stats == nil {
+			return count == 0 && lastWornAt == nil
+		}
+		return count == stats.count && lastWornAt != nil && lastWornAt.Equal(*stats.lastWornAt)
+	}
+
+	for _, outfit := range outfits {
+		stats := outfitStats[outfit.ID]
+		if sameStats(outfit.WearCount, outfit.LastWornAt, stats) {
+			continue
+		}
+		updated := *outfit
+		updated.WearCount, updated.LastWornAt = 0, nil
+		if stats != nil {
+			updated.WearCount, updated.LastWornAt = stats.count, stats.lastWornAt
+		}
+		if err := s.outfitRepo.UpdateOutfit(&updated); err != nil {
+			return fmt.Errorf("failed to update outfit %s: %w", outfit.ID, err)
+		}
+	}
+	for _, item := range items {
+		stats := itemStats[item.ID]
+		if sameStats(item.WearCount, item.LastWornAt, stats) {
+			continue
+		}
+		updated := *item
+		updated.WearCount, updated.LastWornAt = 0, nil
+		if stats != nil {
+			updated.WearCount, updated.LastWornAt = stats.count, stats.lastWornAt
+		}
+		if err := s.wardrobeRepo.UpdateItem(&updated); err != nil {
+			return fmt.Errorf("failed to update item %s: %w", item.ID, err)
+		}
+	}
+	return nil
 }
 
 // GetUserReflections retrieves all reflections for a user
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// normalizePage validates a page request and fills in defaults
+func normalizePage(page domain.PageRequest) (domain.PageRequest, error) {
+	if page.SortBy == "" {
+		page.SortBy = domain.SortByCreatedAt
+		page.Descending = true
+	}
+	if !page.SortBy.IsValid() {
+		return page, fmt.Errorf("invalid sort field: %s", page.SortBy)
+	}
+	if page.Limit < 0 {
+		return page, fmt.Errorf("limit must be between 1 and %d", domain.MaxPageLimit)
+	}
+	if page.Limit == 0 {
+		page.Limit = domain.DefaultPageLimit
+	}
+	if page.Limit > domain.MaxPageLimit {
+		page.Limit = domain.MaxPageLimit
+	}
+	return page, nil
+}
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/lilo/backend/internal/domain"
 )
@@ -14,6 +18,8 @@ type RecommendationServiceImpl struct {
 	recommendationRepo domain.RecommendationRepository
 	wardrobeRepo       domain.WardrobeRepository
 	outfitRepo         domain.OutfitRepository
+	userRepo           domain.UserRepository
+	wardrobeService    domain.WardrobeService
 }
 
 // NewRecommendationService creates a new recommendation service
@@ -21,11 +27,15 @@ func NewRecommendationService(
 	recommendationRepo domain.RecommendationRepository,
 	wardrobeRepo domain.WardrobeRepository,
 	outfitRepo domain.OutfitRepository,
+	userRepo domain.UserRepository,
+	wardrobeService domain.WardrobeService,
 ) domain.RecommendationService {
 	return &RecommendationServiceImpl{
 		recommendationRepo: recommendationRepo,
 		wardrobeRepo:       wardrobeRepo,
 		outfitRepo:         outfitRepo,
+		userRepo:           userRepo,
+		wardrobeService:    wardrobeService,
 	}
 }
 
@@ -36,11 +46,18 @@ func (s *RecommendationServiceImpl) GetDailyRecommendations(userID string) ([]*d
 	}
 
 	// Get user's outfits
-	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, nil)
+	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, domain.OutfitQuery{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user outfits: %w", err)
 	}
 
+	// Skip outfits that need an item which is in the laundry, stored or otherwise
+	// unavailable, preferring those warm or light enough for the season
+	outfits, err = s.wearableOutfits(userID, outfits, currentSeason(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
 	if len(outfits) == 0 {
 		return []*domain.Outfit{}, nil
 	}
@@ -63,33 +80,116 @@ func (s *RecommendationServiceImpl) GetDailyRecommendations(userID string) ([]*d
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	}
 
-	recommendations := shuffled[:numRecommendations]
-
-	// Mark them as recommended
-	for _, outfit := range recommendations {
-		outfit.IsRecommended = true
+	// Mark copies as recommended, since the repository's outfits are shared
+	recommendations := make([]*domain.Outfit, numRecommendations)
+	for i, outfit := range shuffled[:numRecommendations] {
+		recommended := *outfit
+		recommended.IsRecommended = true
+		recommendations[i] = &recommended
 	}
 
 	return recommendations, nil
 }
 
-// GetExploreRecommendations generates explore recommendations for a user with filters
-func (s *RecommendationServiceImpl) GetExploreRecommendations(userID string, filters map[string]interface{}) ([]*domain.Outfit, error) {
+// wearableOutfits filters out outfits that include an owned item the user can't wear
+// right now. Outfits suited to the season come first; the rest are only returned if
+// there are no seasonal ones.
+func (s *RecommendationServiceImpl) wearableOutfits(userID string, outfits []*domain.Outfit, season string) ([]*domain.Outfit, error) {
+	itemsByID, err := s.itemsByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var seasonal, offSeason []*domain.Outfit
+	for _, outfit := range outfits {
+		wearable, inSeason := outfitWearability(outfit, itemsByID, season)
+		switch {
+		case !wearable:
+		case inSeason:
+			seasonal = append(seasonal, outfit)
+		default:
+			offSeason = append(offSeason, outfit)
+		}
+	}
+
+	if len(seasonal) > 0 {
+		return seasonal, nil
+	}
+	return offSeason, nil
+}
+
+// itemsByID returns the user's wardrobe keyed by item ID
+func (s *RecommendationServiceImpl) itemsByID(userID string) (map[string]*domain.ClothingItem, error) {
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user wardrobe: %w", err)
+	}
+
+	itemsByID := make(map[string]*domain.ClothingItem, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+	}
+	return itemsByID, nil
+}
+
+// outfitWearability reports whether every owned item in an outfit can be worn
+// right now, and whether they all suit the season
+func outfitWearability(outfit *domain.Outfit, itemsByID map[string]*domain.ClothingItem, season string) (bool, bool) {
+	inSeason := true
+	for _, itemID := range outfit.Items {
+		item, ok := itemsByID[itemID]
+		if !ok {
+			continue
+		}
+		if item.IsOwned && !item.IsWearable() {
+			return false, false
+		}
+		if !itemSuitsSeason(item, season) {
+			inSeason = false
+		}
+	}
+	return true, inSeason
+}
+
+// GetExploreRecommendations generates one page of explore recommendations for a user matching a query
+func (s *RecommendationServiceImpl) GetExploreRecommendations(userID string, query domain.OutfitQuery, page domain.PageRequest) ([]*domain.Outfit, *domain.PageInfo, error) {
 	if userID == "" {
-		return nil, errors.New("user ID is required")
+		return nil, nil, errors.New("user ID is required")
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Get user's outfits with filters
-	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, filters)
+	itemsByID, err := s.itemsByID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user outfits: %w", err)
+		return nil, nil, err
 	}
 
-	// Simple exploration algorithm: return outfits that match filters
-	// In a real implementation, this would include more sophisticated matching
-	// and could suggest new outfit combinations based on wardrobe items
+	// Get user's outfits matching the query, skipping those that need an item
+	// the user can't wear right now. Pages are topped up from the following
+	// ones, asking only for as many as are missing so every outfit fetched is
+	// either returned or skipped and the last cursor resumes after it.
+	outfits := []*domain.Outfit{}
+	request := page
+	for {
+		fetched, pageInfo, err := s.outfitRepo.ListOutfitsByUserID(userID, query, request)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get user outfits: %w", err)
+		}
+		for _, outfit := range fetched {
+			if wearable, _ := outfitWearability(outfit, itemsByID, ""); wearable {
+				outfits = append(outfits, outfit)
+			}
+		}
 
-	return outfits, nil
+		if len(outfits) == page.Limit || !pageInfo.HasMore {
+			pageInfo.Limit = page.Limit
+			return outfits, pageInfo, nil
+		}
+		request.Cursor = pageInfo.NextCursor
+		request.Limit = page.Limit - len(outfits)
+	}
 }
 
 // SubmitFeedback submits feedback for a recommendation
@@ -124,17 +224,276 @@ func (s *RecommendationServiceImpl) SubmitFeedback(recommendationID string, feed
 	return s.recommendationRepo.UpdateRecommendation(recommendation)
 }
 
+// GetWardrobeGaps finds the items that would unlock the most new outfits for the user's schedule
+func (s *RecommendationServiceImpl) GetWardrobeGaps(userID string, limit int) ([]*domain.WardrobeGap, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	gaps, err := s.analyzeWardrobeGaps(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(gaps) > limit {
+		gaps = gaps[:limit]
+	}
+	return gaps, nil
+}
+
+// AddGapsToWishlist adds the selected gap suggestions to the user's wishlist
+func (s *RecommendationServiceImpl) AddGapsToWishlist(userID string, gapIDs []string) ([]*domain.ClothingItem, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if len(gapIDs) == 0 {
+		return nil, errors.New("at least one gap ID is required")
+	}
+
+	gaps, err := s.analyzeWardrobeGaps(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	gapsByID := make(map[string]*domain.WardrobeGap, len(gaps))
+	for _, gap := range gaps {
+		gapsByID[gap.ID] = gap
+	}
+
+	added := []*domain.ClothingItem{}
+	for _, id := range gapIDs {
+		gap, ok := gapsByID[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown gap: %s", id)
+		}
+		if gap.InWishlist {
+			continue
+		}
+
+		item := &domain.ClothingItem{
+			UserID:      userID,
+			Name:        gapItemName(gap),
+			Category:    gap.Category,
+			Subcategory: gap.Subcategory,
+			Color:       gap.Color,
+			IsOwned:     false,
+		}
+		if err := s.wardrobeService.AddItem(item); err != nil {
+			return nil, fmt.Errorf("failed to add wishlist item: %w", err)
+		}
+		gap.InWishlist = true
+		added = append(added, item)
+	}
+
+	return added, nil
+}
+
+// analyzeWardrobeGaps scores every category, subcategory and color combination the
+// user doesn't own by the number of new outfits it would create, best first
+func (s *RecommendationServiceImpl) analyzeWardrobeGaps(userID string) ([]*domain.WardrobeGap, error) {
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user wardrobe: %w", err)
+	}
+
+	categories, err := s.wardrobeRepo.GetCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	// A missing style profile just means we weight every occasion equally
+	profile, _ := s.userRepo.GetStyleProfile(userID)
+	weights := scheduleWeights(profile)
+
+	var owned []*domain.ClothingItem
+	existing := make(map[string]bool)
+	wishlisted := make(map[string]bool)
+	for _, item := range items {
+		key := gapID(item.Category, item.Subcategory, item.Color)
+		if item.InWardrobe() {
+			owned = append(owned, item)
+			existing[key] = true
+		} else if !item.IsOwned {
+			wishlisted[key] = true
+		}
+	}
+	pools := groupByCategory(owned)
+
+	var gaps []*domain.WardrobeGap
+	for _, category := range categories {
+		for _, subcategory := range category.Subcategories {
+			for _, color := range gapColors(profile) {
+				id := gapID(category.ID, subcategory, color)
+				if existing[id] {
+					continue
+				}
+
+				candidate := &domain.ClothingItem{
+					Category:    category.ID,
+					Subcategory: subcategory,
+					Color:       color,
+				}
+
+				byOccasion := make(map[string]int)
+				total, score := 0, 0
+				for occasion, days := range weights {
+					count := countOutfitsWith(pools, candidate, occasion)
+					if count == 0 {
+						continue
+					}
+					byOccasion[occasion] = count
+					total += count
+					score += count * days
+				}
+				if total == 0 {
+					continue
+				}
+
+				gap := &domain.WardrobeGap{
+					ID:          id,
+					Category:    category.ID,
+					Subcategory: subcategory,
+					Color:       color,
+					IsNeutral:   neutralColors[color],
+					NewOutfits:  total,
+					ByOccasion:  byOccasion,
+					Score:       score,
+					InWishlist:  wishlisted[id],
+				}
+				gap.Summary = gapSummary(gap, weights)
+				gaps = append(gaps, gap)
+			}
+		}
+	}
+
+	sort.SliceStable(gaps, func(i, j int) bool {
+		if gaps[i].Score != gaps[j].Score {
+			return gaps[i].Score > gaps[j].Score
+		}
+		return gaps[i].ID < gaps[j].ID
+	})
+
+	return gaps, nil
+}
+
+// gapColors returns the colors to consider for suggestions: the core neutrals
+// plus any colors the user prefers
+func gapColors(profile *domain.StyleProfile) []string {
+	colors := []string{"black", "white", "grey", "navy", "beige"}
+	seen := make(map[string]bool, len(colors))
+	for _, c := range colors {
+		seen[c] = true
+	}
+	if profile != nil {
+		for _, c := range profile.ColorPreferences {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c != "" && !seen[c] {
+				seen[c] = true
+				colors = append(colors, c)
+			}
+		}
+	}
+	return colors
+}
+
+// gapID builds a stable identifier for a category, subcategory and color combination
+func gapID(category, subcategory, color string) string {
+	return normalizeCategory(category) + ":" +
+		strings.ToLower(strings.TrimSpace(subcategory)) + ":" +
+		strings.ToLower(strings.TrimSpace(color))
+}
+
+// pluralOnlySubcategories are garments that are named in the plural even when singular
+var pluralOnlySubcategories = map[string]bool{
+	"jeans":    true,
+	"pants":    true,
+	"shorts":   true,
+	"leggings": true,
+}
+
+// gapItemName returns a display name such as "Navy Blazer" for a gap suggestion
+func gapItemName(gap *domain.WardrobeGap) string {
+	name := singularSubcategory(gap.Subcategory)
+	if name == "" {
+		name = gap.Category
+	}
+	if gap.Color == "" {
+		return name
+	}
+	return capitalize(gap.Color) + " " + name
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func singularSubcategory(subcategory string) string {
+	lower := strings.ToLower(subcategory)
+	switch {
+	case pluralOnlySubcategories[lower]:
+		return subcategory
+	case strings.HasSuffix(lower, "sses"):
+		return subcategory[:len(subcategory)-2]
+	case strings.HasSuffix(lower, "s"):
+		return subcategory[:len(subcategory)-1]
+	default:
+		return subcategory
+	}
+}
+
+// gapSummary describes a gap in terms of the occasion the user needs most,
+// e.g. "A navy blazer would create 14 new work outfits"
+func gapSummary(gap *domain.WardrobeGap, weights map[string]int) string {
+	best, bestScore := "", -1
+	for _, occasion := range allOccasions {
+		count, ok := gap.ByOccasion[occasion]
+		if !ok {
+			continue
+		}
+		if score := count * weights[occasion]; score > bestScore {
+			best, bestScore = occasion, score
+		}
+	}
+
+	item := strings.ToLower(singularSubcategory(gap.Subcategory))
+	subject := strings.TrimSpace(gap.Color + " " + item)
+	if pluralOnlySubcategories[item] {
+		subject = capitalize(subject)
+	} else {
+		subject = "A " + subject
+	}
+
+	noun := "outfits"
+	if gap.ByOccasion[best] == 1 {
+		noun = "outfit"
+	}
+	return fmt.Sprintf("%s would create %d new %s %s", subject, gap.ByOccasion[best], best, noun)
+}
+
 // generateOutfitRecommendations creates outfit recommendations based on user's wardrobe
 // This is a helper method for future use when we want to generate new outfit combinations
 func (s *RecommendationServiceImpl) generateOutfitRecommendations(userID string) ([]*domain.Outfit, error) {
 	// Get user's clothing items
-	items, err := s.wardrobeRepo.GetItemsByUserID(userID, map[string]interface{}{
-		"isOwned": true, // Only recommend owned items
-	})
+	owned := true // Only recommend owned items
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{IsOwned: &owned})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user wardrobe: %w", err)
 	}
 
+	// Only suggest items that are available to wear today
+	var wearable []*domain.ClothingItem
+	for _, item := range items {
+		if item.IsWearable() {
+			wearable = append(wearable, item)
+		}
+	}
+	items = wearable
+
 	if len(items) < 2 {
 		return []*domain.Outfit{}, nil // Need at least 2 items to make an outfit
 	}
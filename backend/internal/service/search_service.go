@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// DefaultSearchLimit caps the number of hits returned when no limit is given
+const DefaultSearchLimit = 50
+
+// SearchServiceImpl implements SearchService
+type SearchServiceImpl struct {
+	index        domain.SearchIndex
+	wardrobeRepo domain.WardrobeRepository
+	outfitRepo   domain.OutfitRepository
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(index domain.SearchIndex, wardrobeRepo domain.WardrobeRepository, outfitRepo domain.OutfitRepository) domain.SearchService {
+	return &SearchServiceImpl{
+		index:        index,
+		wardrobeRepo: wardrobeRepo,
+		outfitRepo:   outfitRepo,
+	}
+}
+
+// ReindexUser adds all of a user's items and outfits to the index
+func (s *SearchServiceImpl) ReindexUser(userID string) error {
+	items, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return fmt.Errorf("failed to get items: %w", err)
+	}
+	for _, item := range items {
+		s.index.IndexItem(item)
+	}
+
+	outfits, err := s.outfitRepo.GetOutfitsByUserID(userID, domain.OutfitQuery{})
+	if err != nil {
+		return fmt.Errorf("failed to get outfits: %w", err)
+	}
+	for _, outfit := range outfits {
+		s.index.IndexOutfit(outfit)
+	}
+	return nil
+}
+
+// Search runs a keyword search over the user's items and outfits and loads the matching records
+func (s *SearchServiceImpl) Search(query *domain.SearchQuery) (*domain.SearchResults, error) {
+	if query.UserID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	for _, t := range query.Types {
+		if t != domain.SearchTypeItem && t != domain.SearchTypeOutfit {
+			return nil, fmt.Errorf("invalid search type: %s", t)
+		}
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+
+	results, err := s.index.Search(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	// Attach the records, skipping any that were removed after the index was read
+	hits := make([]*domain.SearchHit, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		switch hit.Type {
+		case domain.SearchTypeItem:
+			item, err := s.wardrobeRepo.GetItemByID(hit.ID)
+			if err != nil || item.UserID != query.UserID {
+				continue
+			}
+			hit.Item = item
+		case domain.SearchTypeOutfit:
+			outfit, err := s.outfitRepo.GetOutfitByID(hit.ID)
+			if err != nil || outfit.UserID != query.UserID {
+				continue
+			}
+			hit.Outfit = outfit
+		}
+		hits = append(hits, hit)
+	}
+	results.Hits = hits
+
+	return results, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// sessionSeenResolution is how stale a session's last-seen time may get
+// before it's written again
+const sessionSeenResolution = time.Minute
+
+// SessionServiceImpl implements SessionService. Revoked sessions are also kept
+// in an in-memory denylist, so tokens from them are turned away without a
+// repository lookup. Touches and revocations are each a single conditional
+// repository write, so a touch can't undo a revoke.
+type SessionServiceImpl struct {
+	sessionRepo domain.SessionRepository
+	userRepo    domain.UserRepository
+
+	denylist map[string]time.Time // session ID -> when its tokens expire
+	listMu   sync.RWMutex
+}
+
+// NewSessionService creates a new session service, loading the denylist from
+// the sessions already revoked
+func NewSessionService(sessionRepo domain.SessionRepository, userRepo domain.UserRepository) (domain.SessionService, error) {
+	s := &SessionServiceImpl{
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		denylist:    make(map[string]time.Time),
+	}
+
+	revoked, err := sessionRepo.ListRevoked(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revoked sessions: %w", err)
+	}
+	for _, session := range revoked {
+		s.denylist[session.ID] = session.ExpiresAt
+	}
+	return s, nil
+}
+
+// Touch records a request made in a session, creating the session the first
+// time it's seen
+func (s *SessionServiceImpl) Touch(session *domain.Session) error {
+	if s.IsRevoked(session.ID) {
+		return domain.ErrSessionRevoked
+	}
+
+	seen := *session
+	seen.LastSeenAt = time.Now()
+	err := s.sessionRepo.Touch(&seen, sessionSeenResolution)
+	if errors.Is(err, domain.ErrSessionRevoked) {
+		// Revoked by another instance; remember it so the next token is
+		// turned away up front
+		if revoked, getErr := s.sessionRepo.GetByID(session.ID); getErr == nil {
+			s.deny(revoked)
+		}
+	}
+	return err
+}
+
+// IsRevoked checks the denylist
+func (s *SessionServiceImpl) IsRevoked(sessionID string) bool {
+	s.listMu.RLock()
+	defer s.listMu.RUnlock()
+
+	_, revoked := s.denylist[sessionID]
+	return revoked
+}
+
+// ListSessions returns the user's active sessions
+func (s *SessionServiceImpl) ListSessions(userID string) ([]*domain.Session, error) {
+	sessions, err := s.sessionRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := []*domain.Session{}
+	for _, session := range sessions {
+		if session.IsActive(now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession signs out one of the user's sessions
+func (s *SessionServiceImpl) RevokeSession(userID, sessionID string) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+	return s.revoke(session.ID, time.Now())
+}
+
+// RevokeAllSessions signs out every session but one. Signing out everywhere
+// also rejects tokens issued before now, which covers sessions this server
+// has never seen.
+func (s *SessionServiceImpl) RevokeAllSessions(userID, exceptSessionID string) (int, error) {
+	now := time.Now()
+	if exceptSessionID == "" {
+		// Token issue times have whole-second precision
+		if err := s.userRepo.SetSessionsRevokedAt(userID, now.Truncate(time.Second)); err != nil {
+			return 0, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
+	sessions, err := s.sessionRepo.ListByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == exceptSessionID || !session.IsActive(now) {
+			continue
+		}
+		if err := s.revoke(session.ID, now); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// revoke marks a session revoked and adds it to the denylist
+func (s *SessionServiceImpl) revoke(sessionID string, now time.Time) error {
+	session, err := s.sessionRepo.Revoke(sessionID, now)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.deny(session)
+	return nil
+}
+
+// deny adds a session to the denylist, dropping entries whose tokens have
+// expired since they can't be used anyway
+func (s *SessionServiceImpl) deny(session *domain.Session) {
+	s.listMu.Lock()
+	defer s.listMu.Unlock()
+
+	now := time.Now()
+	for id, expiresAt := range s.denylist {
+		if !now.Before(expiresAt) {
+			delete(s.denylist, id)
+		}
+	}
+	s.denylist[session.ID] = session.ExpiresAt
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// SyncServiceImpl implements SyncService on top of the other services, so
+// mutations pushed by offline clients go through the same validation as
+// online requests
+type SyncServiceImpl struct {
+	changes         domain.ChangeLog
+	userService     domain.UserService
+	wardrobeService domain.WardrobeService
+	outfitService   domain.OutfitService
+}
+
+// NewSyncService creates a new sync service
+func NewSyncService(changes domain.ChangeLog, userService domain.UserService, wardrobeService domain.WardrobeService, outfitService domain.OutfitService) domain.SyncService {
+	return &SyncServiceImpl{
+		changes:         changes,
+		userService:     userService,
+		wardrobeService: wardrobeService,
+		outfitService:   outfitService,
+	}
+}
+
+// Pull returns the user's records that changed since token, with tombstones
+// for deleted ones. An empty token pulls everything.
+func (s *SyncServiceImpl) Pull(userID, token string, limit int) (*domain.SyncPull, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	since, err := parseSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = domain.DefaultSyncLimit
+	}
+	if limit > domain.MaxSyncLimit {
+		limit = domain.MaxSyncLimit
+	}
+
+	changes, hasMore, err := s.changes.Since(userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+
+	pull := &domain.SyncPull{
+		Token:   strconv.FormatInt(since, 10),
+		HasMore: hasMore,
+		Changes: []*domain.SyncChange{},
+	}
+
+	var reflections map[string]*domain.Reflection
+	for _, change := range changes {
+		pull.Token = strconv.FormatInt(change.Seq, 10)
+
+		syncChange := &domain.SyncChange{
+			Type:      change.Type,
+			ID:        change.ID,
+			Deleted:   change.Deleted,
+			ChangedAt: change.ChangedAt,
+		}
+		if !change.Deleted {
+			if change.Type == domain.SyncTypeReflection && reflections == nil {
+				if reflections, err = s.reflectionsByID(userID); err != nil {
+					return nil, err
+				}
+			}
+			// A record that can't be loaded was deleted after the change was
+			// read, and its tombstone will follow; report it as deleted now
+			syncChange.Deleted = !s.attachRecord(userID, syncChange, reflections)
+		}
+		pull.Changes = append(pull.Changes, syncChange)
+	}
+	return pull, nil
+}
+
+// attachRecord loads the current copy of a changed record into the change,
+// reporting whether it still exists
+func (s *SyncServiceImpl) attachRecord(userID string, change *domain.SyncChange, reflections map[string]*domain.Reflection) bool {
+	switch change.Type {
+	case domain.SyncTypeItem:
+		if item, err := s.wardrobeService.GetItem(change.ID); err == nil && item.UserID == userID {
+			change.Item = item
+		}
+		return change.Item != nil
+	case domain.SyncTypeOutfit:
+		if outfit, err := s.outfitService.GetOutfit(change.ID); err == nil && outfit.UserID == userID {
+			change.Outfit = outfit
+		}
+		return change.Outfit != nil
+	case domain.SyncTypeReflection:
+		change.Reflection = reflections[change.ID]
+		return change.Reflection != nil
+	case domain.SyncTypeProfile:
+		if user, err := s.userService.GetUser(userID); err == nil {
+			change.Profile = user
+		}
+		return change.Profile != nil
+	case domain.SyncTypeStyleProfile:
+		if profile, err := s.userService.GetStyleProfile(userID); err == nil {
+			change.StyleProfile = profile
+		}
+		return change.StyleProfile != nil
+	}
+	return false
+}
+
+// Push applies a batch of offline mutations in order. Each mutation succeeds
+// or fails on its own; the results line up with the mutations.
+func (s *SyncServiceImpl) Push(userID string, mutations []*domain.SyncMutation) ([]*domain.SyncResult, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if len(mutations) > domain.MaxSyncMutations {
+		return nil, fmt.Errorf("a sync batch can contain at most %d mutations", domain.MaxSyncMutations)
+	}
+
+	results := make([]*domain.SyncResult, 0, len(mutations))
+	for _, mutation := range mutations {
+		result := &domain.SyncResult{Type: mutation.Type, ID: mutation.ID}
+		if err := s.apply(userID, mutation, result); err != nil {
+			if errors.Is(err, domain.ErrVersionConflict) {
+				result.Status = domain.SyncStatusConflict
+			} else {
+				result.Status = domain.SyncStatusRejected
+			}
+			result.Error = err.Error()
+		} else {
+			result.Status = domain.SyncStatusApplied
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *SyncServiceImpl) apply(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.Op != domain.SyncOpUpsert && mutation.Op != domain.SyncOpDelete {
+		return fmt.Errorf("op must be '%s' or '%s'", domain.SyncOpUpsert, domain.SyncOpDelete)
+	}
+
+	switch mutation.Type {
+	case domain.SyncTypeItem:
+		return s.applyItem(userID, mutation, result)
+	case domain.SyncTypeOutfit:
+		return s.applyOutfit(userID, mutation, result)
+	case domain.SyncTypeReflection:
+		return s.applyReflection(userID, mutation, result)
+	case domain.SyncTypeProfile:
+		return s.applyProfile(userID, mutation, result)
+	case domain.SyncTypeStyleProfile:
+		return s.applyStyleProfile(userID, mutation, result)
+	}
+	return fmt.Errorf("unknown record type: %s", mutation.Type)
+}
+
+func (s *SyncServiceImpl) applyItem(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.ID == "" {
+		return errors.New("item ID is required")
+	}
+
+	existing, err := s.wardrobeService.GetItem(mutation.ID)
+	if err == nil && existing.UserID != userID {
+		return errors.New("unauthorized: item belongs to different user")
+	}
+	found := err == nil
+
+	if mutation.Op == domain.SyncOpDelete {
+		// Deleting what's already gone is a no-op, so retried pushes succeed
+		if !found {
+			return nil
+		}
+		if mutation.BaseVersion != 0 && mutation.BaseVersion != existing.Version {
+			result.Item = existing
+			return domain.ErrVersionConflict
+		}
+		return s.wardrobeService.DeleteItem(mutation.ID)
+	}
+
+	if mutation.Item == nil {
+		return errors.New("item is required")
+	}
+	item := *mutation.Item
+	item.ID = mutation.ID
+	item.UserID = userID
+
+	if !found {
+		if err := s.wardrobeService.AddItem(&item); err != nil {
+			return err
+		}
+		result.Item = &item
+		return nil
+	}
+
+	item.Version = mutation.BaseVersion
+	if err := s.wardrobeService.UpdateItem(&item); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			result.Item = existing
+		}
+		return err
+	}
+	result.Item = &item
+	return nil
+}
+
+func (s *SyncServiceImpl) applyOutfit(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.ID == "" {
+		return errors.New("outfit ID is required")
+	}
+
+	existing, err := s.outfitService.GetOutfit(mutation.ID)
+	if err == nil && existing.UserID != userID {
+		return errors.New("unauthorized: outfit belongs to different user")
+	}
+	found := err == nil
+
+	if mutation.Op == domain.SyncOpDelete {
+		// Deleting what's already gone is a no-op, so retried pushes succeed
+		if !found {
+			return nil
+		}
+		if mutation.BaseVersion != 0 && mutation.BaseVersion != existing.Version {
+			result.Outfit = existing
+			return domain.ErrVersionConflict
+		}
+		return s.outfitService.DeleteOutfit(mutation.ID)
+	}
+
+	if mutation.Outfit == nil {
+		return errors.New("outfit is required")
+	}
+	outfit := *mutation.Outfit
+	outfit.ID = mutation.ID
+	outfit.UserID = userID
+
+	if !found {
+		if err := s.outfitService.CreateOutfit(&outfit); err != nil {
+			return err
+		}
+		result.Outfit = &outfit
+		return nil
+	}
+
+	outfit.Version = mutation.BaseVersion
+	if err := s.outfitService.UpdateOutfit(&outfit); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			result.Outfit = existing
+		}
+		return err
+	}
+	result.Outfit = &outfit
+	return nil
+}
+
+// applyReflection records a reflection made offline. Reflections can't be
+// edited or deleted, so pushing one that already exists just returns it.
+func (s *SyncServiceImpl) applyReflection(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.Op == domain.SyncOpDelete {
+		return errors.New("reflections cannot be deleted")
+	}
+	if mutation.ID == "" {
+		return errors.New("reflection ID is required")
+	}
+	if mutation.Reflection == nil {
+		return errors.New("reflection is required")
+	}
+
+	reflections, err := s.reflectionsByID(userID)
+	if err != nil {
+		return err
+	}
+	if existing, ok := reflections[mutation.ID]; ok {
+		result.Reflection = existing
+		return nil
+	}
+
+	reflection := *mutation.Reflection
+	reflection.ID = mutation.ID
+	reflection.UserID = userID
+	if err := s.outfitService.SubmitReflection(&reflection); err != nil {
+		return err
+	}
+	result.Reflection = &reflection
+	return nil
+}
+
+// applyProfile updates the user's name and picture. Profiles aren't
+// versioned, so the latest push wins.
+func (s *SyncServiceImpl) applyProfile(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.Op == domain.SyncOpDelete {
+		return errors.New("profiles cannot be deleted through sync")
+	}
+	if mutation.Profile == nil {
+		return errors.New("profile is required")
+	}
+
+	user, err := s.userService.GetUser(userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	updated := *user
+	updated.Name = mutation.Profile.Name
+	updated.Picture = mutation.Profile.Picture
+	if err := s.userService.UpdateUser(&updated); err != nil {
+		return err
+	}
+	result.ID = userID
+	result.Profile = &updated
+	return nil
+}
+
+func (s *SyncServiceImpl) applyStyleProfile(userID string, mutation *domain.SyncMutation, result *domain.SyncResult) error {
+	if mutation.Op == domain.SyncOpDelete {
+		return errors.New("style profiles cannot be deleted through sync")
+	}
+	if mutation.StyleProfile == nil {
+		return errors.New("style profile is required")
+	}
+
+	profile := *mutation.StyleProfile
+	profile.UserID = userID
+	profile.Version = mutation.BaseVersion
+	if err := s.userService.SaveStyleProfile(&profile); err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			result.StyleProfile, _ = s.userService.GetStyleProfile(userID)
+		}
+		return err
+	}
+	result.ID = profile.ID
+	result.StyleProfile = &profile
+	return nil
+}
+
+func (s *SyncServiceImpl) reflectionsByID(userID string) (map[string]*domain.Reflection, error) {
+	reflections, err := s.outfitService.GetUserReflections(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reflections: %w", err)
+	}
+	byID := make(map[string]*domain.Reflection, len(reflections))
+	for _, reflection := range reflections {
+		byID[reflection.ID] = reflection
+	}
+	return byID, nil
+}
+
+// parseSyncToken reads the change sequence number a client last synced to
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, domain.ErrInvalidSyncToken
+	}
+	return seq, nil
+}
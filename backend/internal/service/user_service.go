@@ -1,14 +1,11 @@
 package service
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/lilo/backend/internal/domain"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // UserServiceImpl implements UserService
@@ -23,42 +20,87 @@ func NewUserService(userRepo domain.UserRepository) domain.UserService {
 	}
 }
 
-// CreateUser creates a new user with hashed password
-func (s *UserServiceImpl) CreateUser(email, password, name string) (*domain.User, error) {
-	// Check if user already exists
-	if _, err := s.userRepo.GetByEmail(email); err == nil {
-		return nil, errors.New("user already exists")
+// CreateUserFromSupabase creates a user from Supabase authentication. The
+// repository rejects a second user with the same Supabase ID or email.
+func (s *UserServiceImpl) CreateUserFromSupabase(user *domain.User) error {
+	if user.SupabaseID == "" {
+		return errors.New("Supabase ID is required")
 	}
-
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+	user.ProviderName = user.Name
+	user.ProviderPicture = user.Picture
+	if user.Role == "" {
+		user.Role = domain.RoleUser
 	}
+	return s.userRepo.Create(user)
+}
 
-	user := &domain.User{
-		Email:     email,
-		Password:  string(hashedPassword),
-		Name:      name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// ProvisionSupabaseUser returns the user for a Supabase identity, creating
+// them on first sign-in. If another request created the user first, that user
+// is returned instead of an error.
+func (s *UserServiceImpl) ProvisionSupabaseUser(identity *domain.User) (*domain.User, error) {
+	user, err := s.userRepo.GetBySupabaseID(identity.SupabaseID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		user = &domain.User{
+			SupabaseID: identity.SupabaseID,
+			Email:      identity.Email,
+			Name:       identity.Name,
+			Picture:    identity.Picture,
+			Role:       identity.Role,
+		}
+		err = s.CreateUserFromSupabase(user)
+		if errors.Is(err, domain.ErrUserExists) {
+			return s.userRepo.GetBySupabaseID(identity.SupabaseID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
 	}
-
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	return s.refreshFromProvider(user, identity)
 }
 
-// CreateUserFromSupabase creates a user from Supabase authentication
-func (s *UserServiceImpl) CreateUserFromSupabase(user *domain.User) error {
-	// Check if user already exists by Supabase ID
-	if _, err := s.userRepo.GetBySupabaseID(user.SupabaseID); err == nil {
-		return errors.New("user already exists")
+// refreshFromProvider copies the role, email, name and avatar from the identity
+// provider when they've changed there. A name or avatar the user edited in the
+// app is kept until the provider's value changes again.
+func (s *UserServiceImpl) refreshFromProvider(user, identity *domain.User) (*domain.User, error) {
+	updated := *user
+	changed := false
+	if identity.Role != "" && identity.Role != user.Role {
+		updated.Role = identity.Role
+		changed = true
+	}
+	if identity.Email != "" && identity.Email != user.Email {
+		updated.Email = identity.Email
+		changed = true
+	}
+	if identity.Name != "" && identity.Name != user.ProviderName {
+		updated.Name = identity.Name
+		updated.ProviderName = identity.Name
+		changed = true
+	}
+	if identity.Picture != "" && identity.Picture != user.ProviderPicture {
+		updated.Picture = identity.Picture
+		updated.ProviderPicture = identity.Picture
+		changed = true
+	}
+	if !changed {
+		return user, nil
 	}
 
-	return s.userRepo.Create(user)
+	err := s.userRepo.Update(&updated)
+	if errors.Is(err, domain.ErrEmailTaken) {
+		// Keep the old email rather than take another account's
+		updated.Email = user.Email
+		err = s.userRepo.Update(&updated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh user profile: %w", err)
+	}
+	return &updated, nil
 }
 
 // GetUser retrieves a user by ID
@@ -76,6 +118,11 @@ func (s *UserServiceImpl) GetUserBySupabaseID(supabaseID string) (*domain.User,
 	return s.userRepo.GetBySupabaseID(supabaseID)
 }
 
+// BlockSupabaseUser keeps a deleted Supabase identity from being provisioned until the given time
+func (s *UserServiceImpl) BlockSupabaseUser(supabaseID string, until time.Time) error {
+	return s.userRepo.BlockSupabaseID(supabaseID, until)
+}
+
 // UpdateUser updates an existing user
 func (s *UserServiceImpl) UpdateUser(user *domain.User) error {
 	return s.userRepo.Update(user)
@@ -86,49 +133,18 @@ func (s *UserServiceImpl) DeleteUser(id string) error {
 	return s.userRepo.Delete(id)
 }
 
-// Authenticate authenticates a user with email and password
-func (s *UserServiceImpl) Authenticate(email, password string) (*domain.User, string, error) {
-	user, err := s.userRepo.GetByEmail(email)
-	if err != nil {
-		return nil, "", errors.New("invalid credentials")
-	}
-
-	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", errors.New("invalid credentials")
-	}
-
-	// Generate a simple token (in production, use JWT)
-	token, err := s.generateToken()
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate token: %w", err)
-	}
-
-	return user, token, nil
-}
-
-// VerifyToken verifies a token and returns the associated user
-func (s *UserServiceImpl) VerifyToken(token string) (*domain.User, error) {
-	// This is a simplified implementation
-	// In production, you would verify JWT tokens properly
-	return nil, errors.New("token verification not implemented")
-}
-
 // GetStyleProfile retrieves a user's style profile
 func (s *UserServiceImpl) GetStyleProfile(userID string) (*domain.StyleProfile, error) {
 	return s.userRepo.GetStyleProfile(userID)
 }
 
-// SaveStyleProfile saves a user's style profile
+// SaveStyleProfile saves a user's style profile. A zero version overwrites
+// whatever is stored; any other version must match the stored one.
 func (s *UserServiceImpl) SaveStyleProfile(profile *domain.StyleProfile) error {
-	return s.userRepo.SaveStyleProfile(profile)
-}
-
-// generateToken generates a simple random token
-func (s *UserServiceImpl) generateToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+	if profile.Version == 0 {
+		if existing, err := s.userRepo.GetStyleProfile(profile.UserID); err == nil {
+			profile.Version = existing.Version
+		}
 	}
-	return base64.URLEncoding.EncodeToString(bytes), nil
+	return s.userRepo.SaveStyleProfile(profile)
 }
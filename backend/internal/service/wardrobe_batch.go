@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// batchOp applies one batch operation through a wardrobe service and fills in its result
+type batchOp func(s *WardrobeServiceImpl, result *domain.BatchResult) error
+
+// BatchAddItems adds several items to a user's wardrobe
+func (s *WardrobeServiceImpl) BatchAddItems(userID string, items []*domain.ClothingItem, atomic bool) ([]*domain.BatchResult, error) {
+	ops := make([]batchOp, len(items))
+	for i, item := range items {
+		ops[i] = func(s *WardrobeServiceImpl, result *domain.BatchResult) error {
+			if item == nil {
+				return errors.New("item is required")
+			}
+			created := *item
+			created.ID = ""
+			created.UserID = userID
+			if err := s.AddItem(&created); err != nil {
+				return err
+			}
+			result.Status = domain.BatchStatusCreated
+			result.ID = created.ID
+			result.Item = &created
+			return nil
+		}
+	}
+	return s.runBatch(userID, ops, atomic)
+}
+
+// BatchUpdateItems replaces several of a user's items. Each item carries its
+// own ID and, optionally, the version it was based on.
+func (s *WardrobeServiceImpl) BatchUpdateItems(userID string, items []*domain.ClothingItem, atomic bool) ([]*domain.BatchResult, error) {
+	ops := make([]batchOp, len(items))
+	for i, item := range items {
+		ops[i] = func(s *WardrobeServiceImpl, result *domain.BatchResult) error {
+			if item == nil {
+				return errors.New("item is required")
+			}
+			result.ID = item.ID
+			updated := *item
+			updated.UserID = userID
+			if err := s.UpdateItem(&updated); err != nil {
+				return err
+			}
+			result.Status = domain.BatchStatusUpdated
+			result.Item = &updated
+			return nil
+		}
+	}
+	return s.runBatch(userID, ops, atomic)
+}
+
+// BatchDeleteItems deletes several of a user's items
+func (s *WardrobeServiceImpl) BatchDeleteItems(userID string, ids []string, atomic bool) ([]*domain.BatchResult, error) {
+	ops := make([]batchOp, len(ids))
+	for i, id := range ids {
+		ops[i] = func(s *WardrobeServiceImpl, result *domain.BatchResult) error {
+			result.ID = id
+			item, err := s.GetItem(id)
+			if err != nil {
+				return errors.New("item not found")
+			}
+			if item.UserID != userID {
+				return errors.New("unauthorized: item belongs to different user")
+			}
+			if err := s.DeleteItem(id); err != nil {
+				return err
+			}
+			result.Status = domain.BatchStatusDeleted
+			return nil
+		}
+	}
+	return s.runBatch(userID, ops, atomic)
+}
+
+// runBatch applies each operation and reports its outcome. Normally every
+// operation stands alone. In atomic mode the batch runs in a repository
+// transaction and stops at the first failure, undoing what came before it.
+func (s *WardrobeServiceImpl) runBatch(userID string, ops []batchOp, atomic bool) ([]*domain.BatchResult, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("batch must contain at least one operation")
+	}
+	if len(ops) > domain.MaxBatchSize {
+		return nil, fmt.Errorf("%w of %d operations", domain.ErrBatchTooLarge, domain.MaxBatchSize)
+	}
+
+	results := make([]*domain.BatchResult, len(ops))
+	for i := range results {
+		results[i] = &domain.BatchResult{Index: i}
+	}
+
+	apply := func(svc *WardrobeServiceImpl, i int) error {
+		if err := ops[i](svc, results[i]); err != nil {
+			results[i].Status = domain.BatchStatusFailed
+			results[i].Item = nil
+			results[i].Error = err.Error()
+			return err
+		}
+		return nil
+	}
+
+	if !atomic {
+		for i := range ops {
+			apply(s, i)
+		}
+		return results, nil
+	}
+
+	transactor, ok := s.wardrobeRepo.(domain.WardrobeTransactor)
+	if !ok {
+		return nil, domain.ErrTransactionsUnsupported
+	}
+	err := transactor.WithinTransaction(func(tx domain.WardrobeRepository) error {
+		txService := *s
+		txService.wardrobeRepo = tx
+		for i := range ops {
+			if err := apply(&txService, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrTransactionsUnsupported) {
+		return nil, err
+	}
+	if err != nil {
+		// Nothing was kept: mark what had succeeded as undone and what never ran as failed
+		for _, result := range results {
+			switch result.Status {
+			case domain.BatchStatusFailed:
+			case "":
+				result.Status = domain.BatchStatusFailed
+				result.Error = "not attempted: " + domain.ErrBatchAborted.Error()
+			case domain.BatchStatusCreated:
+				result.Status = domain.BatchStatusRolledBack
+				result.ID = ""
+				result.Item = nil
+			default:
+				result.Status = domain.BatchStatusRolledBack
+				result.Item = nil
+			}
+		}
+		return results, domain.ErrBatchAborted
+	}
+	return results, nil
+}
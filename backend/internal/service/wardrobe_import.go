@@ -0,0 +1,528 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+// importFields are the item fields an import can set, in the order they are
+// applied and exported
+var importFields = []string{
+	"name", "category", "subcategory", "color", "season", "brand", "size", "imageUrls",
+	"isOwned", "material", "pattern", "fit", "formality", "warmth", "waterproof",
+	"careInstructions", "notes", "purchasePrice", "currency", "purchaseDate", "status",
+}
+
+// importAliases are common column names from other apps that need no mapping
+var importAliases = map[string]string{
+	"colour":   "color",
+	"seasons":  "season",
+	"type":     "category",
+	"images":   "imageUrls",
+	"imageurl": "imageUrls",
+	"owned":    "isOwned",
+	"care":     "careInstructions",
+	"price":    "purchasePrice",
+}
+
+// exportOnlyFields are written by exports but set by the server, so imports
+// skip them without reporting them as ignored
+var exportOnlyFields = map[string]bool{
+	"id": true, "userid": true, "version": true, "wearcount": true, "lastwornat": true,
+	"statushistory": true, "createdat": true, "updatedat": true,
+}
+
+// colorAliases maps common color spellings to the one used across the wardrobe
+var colorAliases = map[string]string{
+	"navy blue": "navy",
+	"off white": "cream",
+	"ivory":     "cream",
+}
+
+// importRecord is one row of an import file, keyed by item field
+type importRecord struct {
+	row    int
+	fields map[string]interface{}
+}
+
+// ImportItems adds items from a CSV or JSON file to a user's wardrobe. Each row
+// is validated on its own: invalid rows and duplicates of existing items, or of
+// earlier rows, are reported and skipped. A dry run reports without saving.
+func (s *WardrobeServiceImpl) ImportItems(userID string, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	mapping, err := importMapping(opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []importRecord
+	var ignored []string
+	switch opts.Format {
+	case domain.FormatCSV:
+		records, ignored, err = readCSVImport(r, mapping)
+	case domain.FormatJSON:
+		records, ignored, err = readJSONImport(r, mapping)
+	default:
+		return nil, domain.ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.wardrobeRepo.GetItemsByUserID(userID, domain.WardrobeQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wardrobe items: %w", err)
+	}
+	categories, err := s.GetCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	// Remember every item already in the wardrobe, and every row as it's
+	// accepted, so the same piece is never imported twice
+	existingIDs := make(map[string]string, len(existing))
+	for _, item := range existing {
+		existingIDs[duplicateKey(item)] = item.ID
+	}
+	acceptedRows := make(map[string]int)
+
+	report := &domain.ImportReport{
+		DryRun:         opts.DryRun,
+		Total:          len(records),
+		IgnoredColumns: ignored,
+		Rows:           make([]*domain.ImportRow, 0, len(records)),
+	}
+	for _, record := range records {
+		row := &domain.ImportRow{Row: record.row}
+		report.Rows = append(report.Rows, row)
+
+		item, err := buildImportItem(record.fields)
+		if err == nil {
+			row.Name = item.Name
+			item.UserID = userID
+			item.Category = resolveImportCategory(categories, item.Category)
+			item.Color = normalizeColor(item.Color)
+			err = s.prepareNewItem(item)
+		}
+		if err != nil {
+			row.Status = domain.ImportStatusInvalid
+			row.Error = err.Error()
+			report.Invalid++
+			continue
+		}
+
+		key := duplicateKey(item)
+		if id, ok := existingIDs[key]; ok {
+			row.Status = domain.ImportStatusDuplicate
+			row.DuplicateOf = id
+			report.Duplicates++
+			continue
+		}
+		if earlier, ok := acceptedRows[key]; ok {
+			row.Status = domain.ImportStatusDuplicate
+			row.DuplicateOfRow = earlier
+			report.Duplicates++
+			continue
+		}
+		acceptedRows[key] = record.row
+
+		if opts.DryRun {
+			row.Status = domain.ImportStatusValid
+			report.Valid++
+			continue
+		}
+		if err := s.wardrobeRepo.CreateItem(item); err != nil {
+			row.Status = domain.ImportStatusInvalid
+			row.Error = err.Error()
+			report.Invalid++
+			continue
+		}
+		row.Status = domain.ImportStatusCreated
+		row.ID = item.ID
+		report.Created++
+	}
+	return report, nil
+}
+
+// importMapping checks a column mapping and keys it by normalized source column
+func importMapping(mapping map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(mapping))
+	for source, target := range mapping {
+		field, ok := importColumn(target)
+		if !ok {
+			return nil, fmt.Errorf("%w: column %q maps to unknown field %q", domain.ErrInvalidImport, source, target)
+		}
+		resolved[columnKey(source)] = field
+	}
+	return resolved, nil
+}
+
+// resolveColumn returns the item field a source column holds, if any
+func resolveColumn(name string, mapping map[string]string) (string, bool) {
+	if field, ok := mapping[columnKey(name)]; ok {
+		return field, true
+	}
+	return importColumn(name)
+}
+
+// importColumn matches a column name against the item fields and their aliases
+func importColumn(name string) (string, bool) {
+	key := columnKey(name)
+	for _, field := range importFields {
+		if columnKey(field) == key {
+			return field, true
+		}
+	}
+	field, ok := importAliases[key]
+	return field, ok
+}
+
+// columnKey normalizes a column name so "Purchase Price", "purchase_price" and
+// "purchasePrice" all match
+func columnKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// readCSVImport reads a CSV file with a header row
+func readCSVImport(r io.Reader, mapping map[string]string) ([]importRecord, []string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: file is empty", domain.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+	if len(header) > 0 {
+		// Spreadsheet apps often save CSV with a byte order mark
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := make([]string, len(header))
+	columnFor := make(map[string]string)
+	var ignored []string
+	for i, name := range header {
+		field, ok := resolveColumn(name, mapping)
+		if !ok {
+			if strings.TrimSpace(name) != "" && !exportOnlyFields[columnKey(name)] {
+				ignored = append(ignored, name)
+			}
+			continue
+		}
+		if previous, taken := columnFor[field]; taken {
+			return nil, nil, fmt.Errorf("%w: columns %q and %q both hold %s", domain.ErrInvalidImport, previous, name, field)
+		}
+		columnFor[field] = name
+		columns[i] = field
+	}
+	if len(columnFor) == 0 {
+		return nil, nil, fmt.Errorf("%w: no columns match item fields", domain.ErrInvalidImport)
+	}
+
+	var records []importRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+		}
+
+		fields := make(map[string]interface{})
+		for i, value := range values {
+			if i < len(columns) && columns[i] != "" && strings.TrimSpace(value) != "" {
+				fields[columns[i]] = value
+			}
+		}
+		if len(fields) == 0 {
+			continue // blank line
+		}
+		if len(records) == domain.MaxImportRows {
+			return nil, nil, fmt.Errorf("%w of %d", domain.ErrImportTooLarge, domain.MaxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{row: line, fields: fields})
+	}
+	return records, ignored, nil
+}
+
+// readJSONImport reads a JSON list of items, or an object with an "items" list
+// such as a wardrobe export
+func readJSONImport(r io.Reader, mapping map[string]string) ([]importRecord, []string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read import: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var export struct {
+			Items json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+		}
+		data = export.Items
+	}
+
+	var objects []map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, nil, fmt.Errorf("%w: expected a list of items: %v", domain.ErrInvalidImport, err)
+	}
+	if len(objects) > domain.MaxImportRows {
+		return nil, nil, fmt.Errorf("%w of %d", domain.ErrImportTooLarge, domain.MaxImportRows)
+	}
+
+	var ignored []string
+	seenIgnored := make(map[string]bool)
+	records := make([]importRecord, 0, len(objects))
+	for i, object := range objects {
+		fields := make(map[string]interface{})
+		for key, value := range object {
+			field, ok := resolveColumn(key, mapping)
+			if !ok {
+				if !seenIgnored[key] && !exportOnlyFields[columnKey(key)] {
+					seenIgnored[key] = true
+					ignored = append(ignored, key)
+				}
+				continue
+			}
+			if value != nil {
+				fields[field] = value
+			}
+		}
+		records = append(records, importRecord{row: i + 1, fields: fields})
+	}
+	sort.Strings(ignored)
+	return records, ignored, nil
+}
+
+// buildImportItem converts a row to an item. Imported items are owned unless
+// the row says otherwise.
+func buildImportItem(fields map[string]interface{}) (*domain.ClothingItem, error) {
+	item := &domain.ClothingItem{IsOwned: true}
+	for _, field := range importFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		if err := setImportField(item, field, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	return item, nil
+}
+
+func setImportField(item *domain.ClothingItem, field string, value interface{}) error {
+	var err error
+	switch field {
+	case "name":
+		item.Name, err = importString(value)
+	case "category":
+		item.Category, err = importString(value)
+	case "subcategory":
+		item.Subcategory, err = importString(value)
+	case "color":
+		item.Color, err = importString(value)
+	case "season":
+		var seasons []string
+		if seasons, err = importList(value); err == nil {
+			item.Season = make([]string, len(seasons))
+			for i, season := range seasons {
+				item.Season[i] = strings.ToUpper(season[:1]) + strings.ToLower(season[1:])
+			}
+		}
+	case "brand":
+		item.Brand, err = importString(value)
+	case "size":
+		item.Size, err = importString(value)
+	case "imageUrls":
+		item.ImageURLs, err = importList(value)
+	case "isOwned":
+		item.IsOwned, err = importBool(value)
+	case "material":
+		item.Material, err = importString(value)
+	case "pattern":
+		item.Pattern, err = importString(value)
+	case "fit":
+		item.Fit, err = importString(value)
+	case "formality":
+		item.Formality, err = importInt(value)
+	case "warmth":
+		item.Warmth, err = importInt(value)
+	case "waterproof":
+		item.Waterproof, err = importString(value)
+	case "careInstructions":
+		item.CareInstructions, err = importList(value)
+	case "notes":
+		item.Notes, err = importString(value)
+	case "purchasePrice":
+		item.PurchasePrice, err = importPrice(value)
+	case "currency":
+		item.Currency, err = importString(value)
+	case "purchaseDate":
+		var date time.Time
+		if date, err = importDate(value); err == nil {
+			item.PurchaseDate = &date
+		}
+	case "status":
+		var status string
+		if status, err = importString(value); err == nil {
+			item.Status = domain.ItemStatus(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(status)))
+		}
+	}
+	return err
+}
+
+func importString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", errors.New("must be text")
+}
+
+// importList reads a list, given either as an array or as text separated by
+// commas, semicolons or pipes
+func importList(value interface{}) ([]string, error) {
+	var parts []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, element := range v {
+			s, err := importString(element)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, s)
+		}
+	default:
+		s, err := importString(value)
+		if err != nil {
+			return nil, err
+		}
+		parts = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == '|' })
+	}
+
+	list := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list, nil
+}
+
+func importBool(value interface{}) (bool, error) {
+	if b, ok := value.(bool); ok {
+		return b, nil
+	}
+	s, err := importString(value)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "1":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not yes or no", s)
+}
+
+func importInt(value interface{}) (int, error) {
+	s, err := importString(value)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a whole number", s)
+	}
+	return n, nil
+}
+
+// importPrice reads a price, tolerating a leading currency symbol and
+// thousands separators, e.g. "$1,200.00"
+func importPrice(value interface{}) (float64, error) {
+	s, err := importString(value)
+	if err != nil {
+		return 0, err
+	}
+	cleaned := strings.ReplaceAll(strings.TrimLeft(s, "$€£¥ "), ",", "")
+	price, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a price", s)
+	}
+	return price, nil
+}
+
+func importDate(value interface{}) (time.Time, error) {
+	s, err := importString(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date like 2024-03-15", s)
+}
+
+// resolveImportCategory matches a category loosely, so "Top" or "outer wear"
+// find "Tops" and "Outerwear". Unmatched names are left for validation to reject.
+func resolveImportCategory(categories []*domain.ClothingCategory, ref string) string {
+	if category := findCategory(categories, ref); category != nil {
+		return category.ID
+	}
+	key := strings.TrimSuffix(columnKey(ref), "s")
+	for _, category := range categories {
+		if strings.TrimSuffix(columnKey(category.ID), "s") == key || strings.TrimSuffix(columnKey(category.Name), "s") == key {
+			return category.ID
+		}
+	}
+	return ref
+}
+
+// normalizeColor lowercases a color and settles common spelling variants,
+// e.g. "Navy-Blue" -> "navy"
+func normalizeColor(color string) string {
+	color = strings.Join(strings.Fields(strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(color))), " ")
+	if alias, ok := colorAliases[color]; ok {
+		return alias
+	}
+	return color
+}
+
+// duplicateKey identifies the same piece of clothing across imports
+func duplicateKey(item *domain.ClothingItem) string {
+	name := strings.Join(strings.Fields(strings.ToLower(item.Name)), " ")
+	return name + "|" + item.Category + "|" + normalizeColor(item.Color)
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/lilo/backend/internal/domain"
 )
@@ -21,6 +24,14 @@ func NewWardrobeService(wardrobeRepo domain.WardrobeRepository) domain.WardrobeS
 
 // AddItem adds a new clothing item to the wardrobe
 func (s *WardrobeServiceImpl) AddItem(item *domain.ClothingItem) error {
+	if err := s.prepareNewItem(item); err != nil {
+		return err
+	}
+	return s.wardrobeRepo.CreateItem(item)
+}
+
+// prepareNewItem validates a new item and fills in its defaults without saving it
+func (s *WardrobeServiceImpl) prepareNewItem(item *domain.ClothingItem) error {
 	// Validate required fields
 	if item.UserID == "" {
 		return errors.New("user ID is required")
@@ -34,6 +45,15 @@ func (s *WardrobeServiceImpl) AddItem(item *domain.ClothingItem) error {
 	if item.Color == "" {
 		return errors.New("color is required")
 	}
+	if err := validatePurchaseInfo(item); err != nil {
+		return err
+	}
+	if err := s.normalizeTaxonomy(item); err != nil {
+		return err
+	}
+	if err := validateAttributes(item); err != nil {
+		return err
+	}
 
 	// Set default values if not provided
 	if len(item.Season) == 0 {
@@ -43,7 +63,18 @@ func (s *WardrobeServiceImpl) AddItem(item *domain.ClothingItem) error {
 		item.ImageURLs = []string{}
 	}
 
-	return s.wardrobeRepo.CreateItem(item)
+	// New items start their lifecycle in the given state, active by default
+	status := item.CurrentStatus()
+	if !status.IsValid() {
+		return fmt.Errorf("invalid status: %s", item.Status)
+	}
+	item.Status = status
+	item.StatusHistory = []domain.StatusTransition{{To: status, At: time.Now()}}
+
+	// Wear stats are only recorded through outfit reflections
+	item.WearCount = 0
+	item.LastWornAt = nil
+	return nil
 }
 
 // GetItem retrieves a clothing item by ID
@@ -54,12 +85,58 @@ func (s *WardrobeServiceImpl) GetItem(id string) (*domain.ClothingItem, error) {
 	return s.wardrobeRepo.GetItemByID(id)
 }
 
-// GetUserItems retrieves all clothing items for a user with optional filters
-func (s *WardrobeServiceImpl) GetUserItems(userID string, filters map[string]interface{}) ([]*domain.ClothingItem, error) {
+// GetUserItems retrieves one page of a user's clothing items matching a query
+func (s *WardrobeServiceImpl) GetUserItems(userID string, query domain.WardrobeQuery, page domain.PageRequest) ([]*domain.ClothingItem, *domain.PageInfo, error) {
 	if userID == "" {
-		return nil, errors.New("user ID is required")
+		return nil, nil, errors.New("user ID is required")
+	}
+	page, err := normalizePage(page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Accept category names as well as IDs when filtering
+	if !query.Category.IsEmpty() {
+		categories, err := s.GetCategories(userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		query.Category = mapFilter(query.Category, func(category string) string {
+			if match := findCategory(categories, category); match != nil {
+				return match.ID
+			}
+			return category
+		})
+	}
+
+	// Attribute filters use the same spelling rules as attribute values
+	enumFilter := func(filter domain.StringFilter, field string, allowed []string) domain.StringFilter {
+		return mapFilter(filter, func(value string) string {
+			if normalized, err := normalizeEnum(field, value, allowed); err == nil {
+				return normalized
+			}
+			return value
+		})
+	}
+	query.Material = enumFilter(query.Material, "material", domain.Materials)
+	query.Pattern = enumFilter(query.Pattern, "pattern", domain.Patterns)
+	query.Fit = enumFilter(query.Fit, "fit", domain.Fits)
+	query.Waterproof = enumFilter(query.Waterproof, "waterproof", domain.WaterproofLevels)
+	query.Care = enumFilter(query.Care, "care", domain.CareInstructions)
+
+	return s.wardrobeRepo.ListItemsByUserID(userID, query, page)
+}
+
+// mapFilter returns a copy of a filter with fn applied to every value
+func mapFilter(filter domain.StringFilter, fn func(string) string) domain.StringFilter {
+	mapped := domain.StringFilter{}
+	for _, v := range filter.Include {
+		mapped.Include = append(mapped.Include, fn(v))
+	}
+	for _, v := range filter.Exclude {
+		mapped.Exclude = append(mapped.Exclude, fn(v))
 	}
-	return s.wardrobeRepo.GetItemsByUserID(userID, filters)
+	return mapped
 }
 
 // UpdateItem updates an existing clothing item
@@ -79,6 +156,15 @@ func (s *WardrobeServiceImpl) UpdateItem(item *domain.ClothingItem) error {
 	if item.Color == "" {
 		return errors.New("color is required")
 	}
+	if err := validatePurchaseInfo(item); err != nil {
+		return err
+	}
+	if err := s.normalizeTaxonomy(item); err != nil {
+		return err
+	}
+	if err := validateAttributes(item); err != nil {
+		return err
+	}
 
 	// Verify item exists
 	existingItem, err := s.wardrobeRepo.GetItemByID(item.ID)
@@ -91,6 +177,20 @@ func (s *WardrobeServiceImpl) UpdateItem(item *domain.ClothingItem) error {
 		return errors.New("unauthorized: item belongs to different user")
 	}
 
+	// A zero version is an unconditional update; any other version must match
+	if item.Version == 0 {
+		item.Version = existingItem.Version
+	} else if item.Version != existingItem.Version {
+		return domain.ErrVersionConflict
+	}
+
+	// Lifecycle state only changes through ChangeItemStatus
+	item.Status = existingItem.Status
+	item.StatusHistory = existingItem.StatusHistory
+	item.WearCount = existingItem.WearCount
+	item.LastWornAt = existingItem.LastWornAt
+	item.CreatedAt = existingItem.CreatedAt
+
 	return s.wardrobeRepo.UpdateItem(item)
 }
 
@@ -109,7 +209,416 @@ func (s *WardrobeServiceImpl) DeleteItem(id string) error {
 	return s.wardrobeRepo.DeleteItem(id)
 }
 
-// GetCategories retrieves all available clothing categories
-func (s *WardrobeServiceImpl) GetCategories() ([]*domain.ClothingCategory, error) {
-	return s.wardrobeRepo.GetCategories()
+// GetCategories retrieves the built-in clothing categories merged with the user's own
+// categories and subcategories
+func (s *WardrobeServiceImpl) GetCategories(userID string) ([]*domain.ClothingCategory, error) {
+	global, err := s.wardrobeRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	var personal []*domain.ClothingCategory
+	if userID != "" {
+		personal, err = s.wardrobeRepo.GetUserCategories(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	extensions := make(map[string]*domain.ClothingCategory)
+	var custom []*domain.ClothingCategory
+	for _, category := range personal {
+		if category.IsCustom {
+			custom = append(custom, category)
+		} else {
+			extensions[category.ID] = category
+		}
+	}
+
+	merged := make([]*domain.ClothingCategory, 0, len(global)+len(custom))
+	for _, category := range global {
+		m := &domain.ClothingCategory{
+			ID:            category.ID,
+			Name:          category.Name,
+			Subcategories: append([]string{}, category.Subcategories...),
+		}
+		if ext, ok := extensions[category.ID]; ok {
+			m.Subcategories = append(m.Subcategories, ext.Subcategories...)
+			m.CustomSubcategories = append([]string{}, ext.Subcategories...)
+		}
+		merged = append(merged, m)
+	}
+
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
+	})
+	for _, category := range custom {
+		merged = append(merged, &domain.ClothingCategory{
+			ID:                  category.ID,
+			UserID:              category.UserID,
+			Name:                category.Name,
+			Subcategories:       append([]string{}, category.Subcategories...),
+			IsCustom:            true,
+			CustomSubcategories: append([]string{}, category.Subcategories...),
+		})
+	}
+
+	return merged, nil
+}
+
+// AddCategory creates a personal category for the user
+func (s *WardrobeServiceImpl) AddCategory(userID, name string, subcategories []string) (*domain.ClothingCategory, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("category name is required")
+	}
+
+	id := categorySlug(name)
+	if id == "" {
+		return nil, errors.New("category name must contain letters or digits")
+	}
+
+	categories, err := s.GetCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	if findCategory(categories, id) != nil || findCategory(categories, name) != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCategoryExists, name)
+	}
+
+	category := &domain.ClothingCategory{
+		ID:            id,
+		UserID:        userID,
+		Name:          name,
+		Subcategories: []string{},
+		IsCustom:      true,
+	}
+	for _, sub := range subcategories {
+		sub = strings.TrimSpace(sub)
+		if sub != "" && findSubcategory(category, sub) == "" {
+			category.Subcategories = append(category.Subcategories, sub)
+		}
+	}
+
+	if err := s.wardrobeRepo.SaveUserCategory(category); err != nil {
+		return nil, fmt.Errorf("failed to save category: %w", err)
+	}
+	return s.getCategory(userID, id)
+}
+
+// AddSubcategory adds a personal subcategory to a built-in or personal category
+func (s *WardrobeServiceImpl) AddSubcategory(userID, categoryID, name string) (*domain.ClothingCategory, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("subcategory name is required")
+	}
+
+	category, err := s.getCategory(userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if findSubcategory(category, name) != "" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrCategoryExists, name)
+	}
+
+	// Additions to a built-in category live in a personal extension record
+	// that shares the built-in category's ID
+	record := &domain.ClothingCategory{
+		ID:            category.ID,
+		UserID:        userID,
+		Name:          category.Name,
+		Subcategories: append([]string{}, category.CustomSubcategories...),
+		IsCustom:      category.IsCustom,
+	}
+	record.Subcategories = append(record.Subcategories, name)
+
+	if err := s.wardrobeRepo.SaveUserCategory(record); err != nil {
+		return nil, fmt.Errorf("failed to save category: %w", err)
+	}
+	return s.getCategory(userID, category.ID)
+}
+
+// RenameCategory renames one of the user's personal categories and migrates the
+// items filed under it to the new category ID
+func (s *WardrobeServiceImpl) RenameCategory(userID, categoryID, name string) (*domain.ClothingCategory, error) {
+	if userID == "" {
+		return nil, errors.New("user ID is required")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("category name is required")
+	}
+
+	category, err := s.getCategory(userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !category.IsCustom {
+		return nil, errors.New("built-in categories cannot be renamed")
+	}
+
+	newID := categorySlug(name)
+	if newID == "" {
+		return nil, errors.New("category name must contain letters or digits")
+	}
+
+	categories, err := s.GetCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range []*domain.ClothingCategory{findCategory(categories, newID), findCategory(categories, name)} {
+		if other != nil && other.ID != category.ID {
+			return nil, fmt.Errorf("%w: %s", domain.ErrCategoryExists, name)
+		}
+	}
+
+	renamed := &domain.ClothingCategory{
+		ID:            newID,
+		UserID:        userID,
+		Name:          name,
+		Subcategories: category.Subcategories,
+		IsCustom:      true,
+	}
+
+	// Move the category and its items together, so a failure part way leaves
+	// everything under the old name
+	rename := func(repo domain.WardrobeRepository) error {
+		if err := repo.SaveUserCategory(renamed); err != nil {
+			return fmt.Errorf("failed to save category: %w", err)
+		}
+		if newID == category.ID {
+			return nil
+		}
+
+		items, err := repo.GetItemsByUserID(userID, domain.WardrobeQuery{
+			Category: domain.StringFilter{Include: []string{category.ID}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get items for category: %w", err)
+		}
+		for _, item := range items {
+			migrated := *item
+			migrated.Category = newID
+			if err := repo.UpdateItem(&migrated); err != nil {
+				return fmt.Errorf("failed to migrate item %s: %w", item.ID, err)
+			}
+		}
+
+		if err := repo.DeleteUserCategory(userID, category.ID); err != nil {
+			return fmt.Errorf("failed to remove old category: %w", err)
+		}
+		return nil
+	}
+
+	transactor, ok := s.wardrobeRepo.(domain.WardrobeTransactor)
+	if !ok {
+		return nil, domain.ErrTransactionsUnsupported
+	}
+	if err := transactor.WithinTransaction(rename); err != nil {
+		return nil, err
+	}
+
+	return s.getCategory(userID, newID)
+}
+
+// validateAttributes checks an item's optional structured attributes against their
+// enumerations and rewrites them to their canonical form
+func validateAttributes(item *domain.ClothingItem) error {
+	var err error
+	if item.Material, err = normalizeEnum("material", item.Material, domain.Materials); err != nil {
+		return err
+	}
+	if item.Pattern, err = normalizeEnum("pattern", item.Pattern, domain.Patterns); err != nil {
+		return err
+	}
+	if item.Fit, err = normalizeEnum("fit", item.Fit, domain.Fits); err != nil {
+		return err
+	}
+	if item.Waterproof, err = normalizeEnum("waterproof", item.Waterproof, domain.WaterproofLevels); err != nil {
+		return err
+	}
+
+	care := make([]string, 0, len(item.CareInstructions))
+	seen := make(map[string]bool)
+	for _, instruction := range item.CareInstructions {
+		normalized, err := normalizeEnum("care instruction", instruction, domain.CareInstructions)
+		if err != nil {
+			return err
+		}
+		if normalized != "" && !seen[normalized] {
+			seen[normalized] = true
+			care = append(care, normalized)
+		}
+	}
+	item.CareInstructions = care
+
+	if item.Formality != 0 && (item.Formality < domain.MinFormality || item.Formality > domain.MaxFormality) {
+		return fmt.Errorf("formality must be between %d and %d", domain.MinFormality, domain.MaxFormality)
+	}
+	if item.Warmth != 0 && (item.Warmth < domain.MinWarmth || item.Warmth > domain.MaxWarmth) {
+		return fmt.Errorf("warmth must be between %d and %d", domain.MinWarmth, domain.MaxWarmth)
+	}
+	return nil
+}
+
+// normalizeEnum matches value against allowed ignoring case, spaces and dashes,
+// e.g. "Polka Dot" -> "polka_dot". An empty value is left unset.
+func normalizeEnum(field, value string, allowed []string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(value))
+	for _, a := range allowed {
+		if a == key {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q: must be one of %s", field, value, strings.Join(allowed, ", "))
+}
+
+// getCategory looks up a category in the user's merged taxonomy by ID
+func (s *WardrobeServiceImpl) getCategory(userID, categoryID string) (*domain.ClothingCategory, error) {
+	categories, err := s.GetCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.ID == categoryID {
+			return category, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrCategoryNotFound, categoryID)
+}
+
+// normalizeTaxonomy validates an item's category and subcategory against the
+// user's taxonomy and rewrites them to their canonical form
+func (s *WardrobeServiceImpl) normalizeTaxonomy(item *domain.ClothingItem) error {
+	categories, err := s.GetCategories(item.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	category := findCategory(categories, item.Category)
+	if category == nil {
+		return fmt.Errorf("unknown category: %s", item.Category)
+	}
+	item.Category = category.ID
+
+	if item.Subcategory != "" {
+		subcategory := findSubcategory(category, item.Subcategory)
+		if subcategory == "" {
+			return fmt.Errorf("unknown subcategory %q for category %s", item.Subcategory, category.Name)
+		}
+		item.Subcategory = subcategory
+	}
+	return nil
+}
+
+// findCategory finds a category by ID or name, ignoring case
+func findCategory(categories []*domain.ClothingCategory, ref string) *domain.ClothingCategory {
+	ref = strings.TrimSpace(ref)
+	for _, category := range categories {
+		if strings.EqualFold(category.ID, ref) || strings.EqualFold(category.Name, ref) {
+			return category
+		}
+	}
+	return nil
+}
+
+// findSubcategory returns the canonical spelling of a subcategory, or "" if the
+// category doesn't have it
+func findSubcategory(category *domain.ClothingCategory, ref string) string {
+	ref = strings.TrimSpace(ref)
+	for _, sub := range category.Subcategories {
+		if strings.EqualFold(sub, ref) {
+			return sub
+		}
+	}
+	return ""
+}
+
+// categorySlug derives a category ID from its name, e.g. "Kimonos & Robes" -> "kimonos-robes"
+func categorySlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// ChangeItemStatus moves an item to a new lifecycle state and records the transition.
+// Items that leave the wardrobe are kept so past outfits still reference them.
+func (s *WardrobeServiceImpl) ChangeItemStatus(id string, status domain.ItemStatus, note string) (*domain.ClothingItem, error) {
+	if id == "" {
+		return nil, errors.New("item ID is required")
+	}
+	if !status.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
+
+	item, err := s.wardrobeRepo.GetItemByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("item not found: %w", err)
+	}
+	if !item.IsOwned {
+		return nil, errors.New("wishlist items have no lifecycle status")
+	}
+
+	current := item.CurrentStatus()
+	if !current.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", domain.ErrInvalidStatusTransition, current, status)
+	}
+
+	updated := *item
+	updated.Status = status
+	updated.StatusHistory = append(append([]domain.StatusTransition{}, item.StatusHistory...), domain.StatusTransition{
+		From: current,
+		To:   status,
+		At:   time.Now(),
+		Note: note,
+	})
+
+	if err := s.wardrobeRepo.UpdateItem(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// validatePurchaseInfo checks the optional purchase price, currency and date of an item
+func validatePurchaseInfo(item *domain.ClothingItem) error {
+	if item.PurchasePrice < 0 {
+		return errors.New("purchase price cannot be negative")
+	}
+
+	item.Currency = strings.ToUpper(strings.TrimSpace(item.Currency))
+	if item.PurchasePrice > 0 && item.Currency == "" {
+		return errors.New("currency is required when a purchase price is set")
+	}
+	if item.Currency != "" {
+		if len(item.Currency) != 3 || strings.Trim(item.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return errors.New("currency must be a three-letter ISO 4217 code")
+		}
+	}
+
+	if item.PurchaseDate != nil && item.PurchaseDate.After(time.Now()) {
+		return errors.New("purchase date cannot be in the future")
+	}
+	return nil
 }
@@ -4,9 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lilo/backend/internal/domain"
@@ -19,12 +20,97 @@ type SupabaseJWTClaims struct {
 	Email        string                 `json:"email"`
 	Sub          string                 `json:"sub"` // Subject (user ID)
 	Role         string                 `json:"role"`
+	SessionID    string                 `json:"session_id"`
 	AppMetadata  map[string]interface{} `json:"app_metadata"`
 	UserMetadata map[string]interface{} `json:"user_metadata"`
 }
 
+// AppRole returns the user's app role from app_metadata. A custom access
+// token hook may instead set the top-level role claim to an app role.
+func (c *SupabaseJWTClaims) AppRole() domain.Role {
+	return domain.RoleFromMetadata(c.AppMetadata, c.Role)
+}
+
+// TokenVerifierConfig configures how access tokens are verified. Tokens signed
+// with the project's shared secret (HS256) are accepted when HMACSecret is set,
+// and tokens signed with an asymmetric key (RS256, ES256, EdDSA) when Keys is.
+// Issuer and Audience are checked when set.
+type TokenVerifierConfig struct {
+	HMACSecret string
+	Keys       *JWKSCache
+	Issuer     string
+	Audience   string
+}
+
+// TokenVerifier validates Supabase access tokens
+type TokenVerifier struct {
+	config TokenVerifierConfig
+	parser *jwt.Parser
+}
+
+// NewTokenVerifier creates a verifier for the given configuration
+func NewTokenVerifier(config TokenVerifierConfig) *TokenVerifier {
+	var methods []string
+	if config.HMACSecret != "" {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if config.Keys != nil {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512",
+			"ES256", "ES384", "ES512", "EdDSA")
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &TokenVerifier{
+		config: config,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+// Verify parses a token and checks its signature and claims
+func (v *TokenVerifier) Verify(ctx context.Context, tokenString string) (*SupabaseJWTClaims, error) {
+	claims := &SupabaseJWTClaims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			// Return the secret key for validation
+			if v.config.HMACSecret == "" {
+				return nil, errors.New("HMAC signed tokens are not accepted")
+			}
+			return []byte(v.config.HMACSecret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+			// Look up the signing key named by the token
+			if v.config.Keys == nil {
+				return nil, errors.New("asymmetric signed tokens are not accepted")
+			}
+			kid, _ := token.Header["kid"].(string)
+			return v.config.Keys.Key(ctx, kid, token.Method.Alg())
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
 // AuthMiddleware creates a middleware that validates JWT tokens from Supabase
-func AuthMiddleware(userService domain.UserService, jwtSecret string) func(http.Handler) http.Handler {
+// and the app's own API keys. Requests made with an API key carry it in the
+// context, for RequireScope to check. Tokens from signed-out sessions are
+// rejected, and the session is recorded as seen otherwise.
+func AuthMiddleware(userService domain.UserService, verifier *TokenVerifier, apiKeys domain.APIKeyService, sessions domain.SessionService) func(http.Handler) http.Handler {
+	provisioning := &provisionGroup{calls: make(map[string]*provisionCall)}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get the Authorization header
@@ -47,74 +133,155 @@ func AuthMiddleware(userService domain.UserService, jwtSecret string) func(http.
 				return
 			}
 
-			// Parse and validate the token
-			token, err := jwt.ParseWithClaims(tokenString, &SupabaseJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-				// Validate the signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			// API keys are looked up; anything else must be a Supabase JWT
+			ctx := r.Context()
+			var user *domain.User
+			if strings.HasPrefix(tokenString, domain.APIKeyPrefix) {
+				key, err := apiKeys.Authenticate(tokenString)
+				if errors.Is(err, domain.ErrAPIKeyInvalid) {
+					response.Unauthorized(w, "Invalid API key")
+					return
+				}
+				if err != nil {
+					response.InternalServerError(w, "Failed to check API key")
+					return
 				}
 
-				// Return the secret key for validation
-				return []byte(jwtSecret), nil
-			})
-
-			if err != nil {
-				response.Unauthorized(w, "Invalid token: "+err.Error())
-				return
-			}
-
-			// Check if the token is valid
-			if !token.Valid {
-				response.Unauthorized(w, "Invalid token")
-				return
-			}
-
-			// Extract claims
-			claims, ok := token.Claims.(*SupabaseJWTClaims)
-			if !ok {
-				response.Unauthorized(w, "Invalid token claims")
-				return
-			}
+				user, err = userService.GetUser(key.UserID)
+				if err != nil {
+					response.Unauthorized(w, "User not found")
+					return
+				}
+				ctx = context.WithValue(ctx, domain.ContextKeyAPIKey, key)
+			} else {
+				// Parse and validate the token
+				claims, err := verifier.Verify(ctx, tokenString)
+				if err != nil {
+					response.Unauthorized(w, "Invalid token: "+err.Error())
+					return
+				}
+				// Users are provisioned by subject, so a token must name one
+				if claims.Sub == "" {
+					response.Unauthorized(w, "Invalid token: missing subject")
+					return
+				}
 
-			// Check if token is expired
-			if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-				response.Unauthorized(w, "Token expired")
-				return
-			}
+				// Turn away signed-out sessions before doing any work for them
+				sessionID := claims.SessionID
+				if sessionID == "" {
+					sessionID = claims.ID
+				}
+				if sessionID != "" && sessions.IsRevoked(sessionID) {
+					response.Unauthorized(w, "Session has been signed out")
+					return
+				}
 
-			// Get user from database using the Supabase user ID
-			user, err := userService.GetUserBySupabaseID(claims.Sub)
-			if err != nil {
-				// If user doesn't exist in our database yet, create a new user
-				if errors.Is(err, domain.ErrUserNotFound) {
-					// Create a new user in our database
-					user = &domain.User{
+				// Get or create the user, sharing the work with any concurrent
+				// request for the same Supabase user
+				user, err = provisioning.do(claims.Sub, func() (*domain.User, error) {
+					return userService.ProvisionSupabaseUser(&domain.User{
 						SupabaseID: claims.Sub,
 						Email:      claims.Email,
 						Name:       getStringFromMap(claims.UserMetadata, "name"),
 						Picture:    getStringFromMap(claims.UserMetadata, "avatar_url"),
-						CreatedAt:  time.Now(),
-						UpdatedAt:  time.Now(),
-					}
+						Role:       claims.AppRole(),
+					})
+				})
+				if errors.Is(err, domain.ErrEmailTaken) {
+					response.Error(w, http.StatusConflict, "An account with this email already exists")
+					return
+				}
+				if errors.Is(err, domain.ErrUserDeleted) {
+					response.Unauthorized(w, "Account has been deleted")
+					return
+				}
+				if err != nil {
+					response.InternalServerError(w, "Failed to create user")
+					return
+				}
 
-					err = userService.CreateUserFromSupabase(user)
+				// Tokens issued before the user signed out everywhere are revoked too
+				if user.SessionsRevokedAt != nil &&
+					(claims.IssuedAt == nil || claims.IssuedAt.Time.Before(*user.SessionsRevokedAt)) {
+					response.Unauthorized(w, "Session has been signed out")
+					return
+				}
+
+				// Record the session
+				if sessionID != "" {
+					err = sessions.Touch(&domain.Session{
+						ID:        sessionID,
+						UserID:    user.ID,
+						UserAgent: r.UserAgent(),
+						IPAddress: clientIP(r),
+						ExpiresAt: claims.ExpiresAt.Time,
+					})
+					if errors.Is(err, domain.ErrSessionRevoked) || errors.Is(err, domain.ErrSessionNotFound) {
+						response.Unauthorized(w, "Session has been signed out")
+						return
+					}
 					if err != nil {
-						response.InternalServerError(w, "Failed to create user")
+						response.InternalServerError(w, "Failed to record session")
 						return
 					}
-				} else {
-					response.Unauthorized(w, "User not found")
-					return
+					ctx = context.WithValue(ctx, domain.ContextKeySessionID, sessionID)
 				}
 			}
+			if user.IsDisabled() {
+				response.Forbidden(w, "Account is disabled")
+				return
+			}
 
 			// Add the user to the request context
-			ctx := context.WithValue(r.Context(), domain.ContextKeyUser, user)
+			ctx = context.WithValue(ctx, domain.ContextKeyUser, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// provisionGroup runs one user lookup-or-create at a time per Supabase ID;
+// concurrent requests for the same ID wait for and share its result
+type provisionGroup struct {
+	calls map[string]*provisionCall
+	mu    sync.Mutex
+}
+
+type provisionCall struct {
+	done chan struct{}
+	user *domain.User
+	err  error
+}
+
+func (g *provisionGroup) do(supabaseID string, fn func() (*domain.User, error)) (*domain.User, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[supabaseID]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.user, call.err
+	}
+	call := &provisionCall{done: make(chan struct{})}
+	g.calls[supabaseID] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, supabaseID)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.user, call.err = fn()
+	return call.user, call.err
+}
+
+// clientIP returns the address the request came from, without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // Helper function to extract string values from a map
 func getStringFromMap(m map[string]interface{}, key string) string {
 	if val, ok := m[key]; ok {
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/internal/repository"
+	"github.com/lilo/backend/internal/service"
+)
+
+const testJWTSecret = "test-secret"
+
+// authFixture wires AuthMiddleware to in-memory repositories
+type authFixture struct {
+	users    domain.UserRepository
+	apiKeys  domain.APIKeyService
+	sessions domain.SessionService
+	handler  http.Handler
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	users := repository.NewUserRepository()
+	sessions, err := service.NewSessionService(repository.NewSessionRepository(), users)
+	if err != nil {
+		t.Fatalf("NewSessionService: %v", err)
+	}
+	f := &authFixture{
+		users:    users,
+		apiKeys:  service.NewAPIKeyService(repository.NewAPIKeyRepository()),
+		sessions: sessions,
+	}
+	verifier := NewTokenVerifier(TokenVerifierConfig{HMACSecret: testJWTSecret})
+	authenticate := AuthMiddleware(service.NewUserService(users), verifier, f.apiKeys, sessions)
+	f.handler = authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+		if !ok {
+			t.Error("no user in context")
+			return
+		}
+		w.Header().Set("X-User-Email", user.Email)
+	}))
+	return f
+}
+
+// signToken signs claims for a Supabase user with the test secret
+func signToken(t *testing.T, supabaseID string, issuedAt, expiresAt time.Time, secret string) string {
+	t.Helper()
+	claims := &SupabaseJWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Sub:       supabaseID,
+		Email:     supabaseID + "@example.com",
+		SessionID: "session-" + supabaseID,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, f *authFixture) string // returns the Authorization header
+		wantCode  int
+		wantEmail string
+	}{
+		{
+			name:     "missing header",
+			setup:    func(t *testing.T, f *authFixture) string { return "" },
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "not a bearer token",
+			setup:    func(t *testing.T, f *authFixture) string { return "Basic dXNlcjpwYXNz" },
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid token provisions the user",
+			setup: func(t *testing.T, f *authFixture) string {
+				return "Bearer " + signToken(t, "new", now, now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode:  http.StatusOK,
+			wantEmail: "new@example.com",
+		},
+		{
+			name: "token signed with another secret",
+			setup: func(t *testing.T, f *authFixture) string {
+				return "Bearer " + signToken(t, "forged", now, now.Add(time.Hour), "other-secret")
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "expired token",
+			setup: func(t *testing.T, f *authFixture) string {
+				return "Bearer " + signToken(t, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour), testJWTSecret)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "token without a subject",
+			setup: func(t *testing.T, f *authFixture) string {
+				return "Bearer " + signToken(t, "", now, now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "disabled user",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "disabled", Email: "disabled@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if _, err := f.users.SetDisabledAt(user.ID, &now); err != nil {
+					t.Fatalf("SetDisabledAt: %v", err)
+				}
+				return "Bearer " + signToken(t, "disabled", now, now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "API key",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "keyed", Email: "keyed@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				_, secret, err := f.apiKeys.CreateKey(user.ID, "cli", []domain.Scope{domain.ScopeProfileRead}, 0)
+				if err != nil {
+					t.Fatalf("CreateKey: %v", err)
+				}
+				return "Bearer " + secret
+			},
+			wantCode:  http.StatusOK,
+			wantEmail: "keyed@example.com",
+		},
+		{
+			name: "revoked API key",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "revoked", Email: "revoked@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				key, secret, err := f.apiKeys.CreateKey(user.ID, "cli", []domain.Scope{domain.ScopeProfileRead}, 0)
+				if err != nil {
+					t.Fatalf("CreateKey: %v", err)
+				}
+				if _, err := f.apiKeys.RevokeKey(user.ID, key.ID); err != nil {
+					t.Fatalf("RevokeKey: %v", err)
+				}
+				return "Bearer " + secret
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "unknown API key",
+			setup:    func(t *testing.T, f *authFixture) string { return "Bearer " + domain.APIKeyPrefix + "0123456789" },
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "token issued before signing out everywhere",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "signedout", Email: "signedout@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := f.users.SetSessionsRevokedAt(user.ID, now); err != nil {
+					t.Fatalf("SetSessionsRevokedAt: %v", err)
+				}
+				return "Bearer " + signToken(t, "signedout", now.Add(-time.Minute), now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "identity deleted by the provider",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "deleted", Email: "deleted@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := f.users.Delete(user.ID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				if err := f.users.BlockSupabaseID("deleted", now.Add(time.Hour)); err != nil {
+					t.Fatalf("BlockSupabaseID: %v", err)
+				}
+				return "Bearer " + signToken(t, "deleted", now, now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "account deleted in the app signs up again",
+			setup: func(t *testing.T, f *authFixture) string {
+				user := &domain.User{SupabaseID: "returning", Email: "returning@example.com"}
+				if err := f.users.Create(user); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				if err := f.users.Delete(user.ID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				return "Bearer " + signToken(t, "returning", now, now.Add(time.Hour), testJWTSecret)
+			},
+			wantCode:  http.StatusOK,
+			wantEmail: "returning@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+			if header := tt.setup(t, f); header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			f.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := rec.Header().Get("X-User-Email"); got != tt.wantEmail {
+				t.Errorf("user email = %q, want %q", got, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareProvisionsOnce(t *testing.T) {
+	const requests = 20
+	f := newAuthFixture(t)
+	now := time.Now()
+	token := "Bearer " + signToken(t, "concurrent", now, now.Add(time.Hour), testJWTSecret)
+
+	// A user's first requests often arrive together; they must share one account
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+			req.Header.Set("Authorization", token)
+			rec := httptest.NewRecorder()
+			f.handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	users, _, err := f.users.ListUsers(domain.UserQuery{}, domain.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 1 {
+		t.Errorf("users = %d, want 1", len(users))
+	}
+}
+
+func TestAuthMiddlewareRejectsSignedOutSession(t *testing.T) {
+	f := newAuthFixture(t)
+	now := time.Now()
+	token := "Bearer " + signToken(t, "session", now, now.Add(time.Hour), testJWTSecret)
+
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+		req.Header.Set("Authorization", token)
+		rec := httptest.NewRecorder()
+		f.handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", code, http.StatusOK)
+	}
+	user, err := f.users.GetBySupabaseID("session")
+	if err != nil {
+		t.Fatalf("GetBySupabaseID: %v", err)
+	}
+	if err := f.sessions.RevokeSession(user.ID, "session-session"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Fatalf("status after sign-out = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/pkg/response"
+)
+
+// RequirePermission creates a middleware that lets a request through only if
+// the authenticated user's role grants the permission. It must run after
+// AuthMiddleware.
+func RequirePermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+			if !ok {
+				response.Unauthorized(w, "User not found in context")
+				return
+			}
+
+			if !user.Role.Can(permission) {
+				response.Forbidden(w, "Missing permission: "+string(permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope creates a middleware that lets API key requests through only if
+// the key was granted every listed scope. Signed-in sessions aren't scoped and
+// always pass. It must run after AuthMiddleware.
+func RequireScope(scopes ...domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := r.Context().Value(domain.ContextKeyAPIKey).(*domain.APIKey); ok {
+				for _, scope := range scopes {
+					if !key.HasScope(scope) {
+						response.Forbidden(w, "API key is missing scope: "+string(scope))
+						return
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession creates a middleware that turns away API keys, for routes
+// such as key management that need the user to be signed in. It must run
+// after AuthMiddleware.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(domain.ContextKeyAPIKey).(*domain.APIKey); ok {
+			response.Forbidden(w, "API keys cannot be used for this request")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
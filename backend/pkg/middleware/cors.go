@@ -2,23 +2,161 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/pkg/response"
 )
 
-// CorsMiddleware adds CORS headers to responses
-func CorsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CorsConfig configures which cross-origin requests browsers may make.
+// AllowedOrigins holds exact origins or patterns with one *, which stands for
+// any run of letters, digits, dots and hyphens, so https://*.example.com
+// covers subdomains and http://localhost:* any port. A lone * allows any
+// origin. AllowedHeaders may also be *, allowing whatever the browser asks for.
+type CorsConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// RouteMatcher finds the handler registered for a request, returning an empty
+// pattern when there is none; *http.ServeMux implements it
+type RouteMatcher interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// CorsMiddleware adds CORS headers for allowed origins and answers preflight
+// requests. Preflights only advertise the methods the routes actually serve
+// for the requested path, and paths with no route aren't found.
+func CorsMiddleware(config CorsConfig, routes RouteMatcher) func(http.Handler) http.Handler {
+	anyOrigin := false
+	for _, origin := range config.AllowedOrigins {
+		anyOrigin = anyOrigin || origin == "*"
+	}
+	anyHeader := false
+	for _, header := range config.AllowedHeaders {
+		anyHeader = anyHeader || header == "*"
+	}
+	// Credentialed responses must name the origin rather than use *
+	wildcard := anyOrigin && !config.AllowCredentials
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the origin, so caches must keep them
+			// apart, including from responses to requests without one
+			header := w.Header()
+			if !wildcard {
+				header.Add("Vary", "Origin")
+			}
+
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed := anyOrigin || originAllowed(config.AllowedOrigins, origin)
+
+			// Handle preflight requests
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+				if !allowed {
+					response.Forbidden(w, "Origin not allowed")
+					return
+				}
+
+				methods := routeMethods(routes, r, config.AllowedMethods)
+				if len(methods) == 0 {
+					response.Error(w, http.StatusNotFound, "Not found")
+					return
+				}
+
+				setAllowOrigin(header, origin, wildcard, config.AllowCredentials)
+				header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				if anyHeader {
+					if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+						header.Set("Access-Control-Allow-Headers", requested)
+					}
+				} else if len(config.AllowedHeaders) > 0 {
+					header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+				}
+				if config.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if allowed {
+				setAllowOrigin(header, origin, wildcard, config.AllowCredentials)
+				if len(config.ExposedHeaders) > 0 {
+					header.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
+			}
+
+			// Call the next handler
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setAllowOrigin(header http.Header, origin string, wildcard, credentials bool) {
+	if wildcard {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// routeMethods returns the allowed methods that have a route for the request's path
+func routeMethods(routes RouteMatcher, r *http.Request, allowed []string) []string {
+	var methods []string
+	for _, method := range allowed {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := routes.Handler(probe); pattern != "" {
+			methods = append(methods, method)
 		}
+	}
+	return methods
+}
 
-		// Call the next handler
-		next.ServeHTTP(w, r)
-	})
+// originAllowed reports whether origin matches one of the allowed origins or patterns
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		prefix, suffix, isPattern := strings.Cut(pattern, "*")
+		if !isPattern {
+			if origin == pattern {
+				return true
+			}
+			continue
+		}
+		if len(origin) <= len(prefix)+len(suffix) ||
+			!strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		if wildcardPart(origin[len(prefix) : len(origin)-len(suffix)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardPart reports whether s could be a run of host labels or a port
+func wildcardPart(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '-') {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCorsMiddleware(t *testing.T) {
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /api/wardrobe", func(w http.ResponseWriter, r *http.Request) {})
+	routes.HandleFunc("POST /api/wardrobe", func(w http.ResponseWriter, r *http.Request) {})
+
+	config := CorsConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.preview.example.com", "http://localhost:*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name       string
+		config     CorsConfig
+		method     string
+		path       string
+		header     map[string]string
+		wantCode   int
+		wantHeader map[string]string
+	}{
+		{
+			name:     "request without an origin still varies by origin",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Vary":                        "Origin",
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:     "allowed origin",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://app.example.com"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "ETag",
+			},
+		},
+		{
+			name:     "origin matching a subdomain pattern",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://pr-12.preview.example.com"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin": "https://pr-12.preview.example.com",
+			},
+		},
+		{
+			name:     "origin matching a port pattern",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "http://localhost:5173"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin": "http://localhost:5173",
+			},
+		},
+		{
+			name:     "pattern doesn't match across a path",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://evil.com/.preview.example.com"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:     "disallowed origin",
+			config:   config,
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://evil.example.org"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name: "wildcard origin without credentials",
+			config: CorsConfig{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{"GET"},
+			},
+			method:   http.MethodGet,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://anywhere.example.org"},
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:     "preflight advertises the routed methods",
+			config:   config,
+			method:   http.MethodOptions,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST"},
+			wantCode: http.StatusNoContent,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Authorization, Content-Type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:     "preflight from a disallowed origin",
+			config:   config,
+			method:   http.MethodOptions,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://evil.example.org", "Access-Control-Request-Method": "POST"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "preflight for a path with no route",
+			config:   config,
+			method:   http.MethodOptions,
+			path:     "/api/missing",
+			header:   map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "preflight echoes requested headers when any are allowed",
+			config: CorsConfig{
+				AllowedOrigins: []string{"https://app.example.com"},
+				AllowedMethods: []string{"GET"},
+				AllowedHeaders: []string{"*"},
+			},
+			method:   http.MethodOptions,
+			path:     "/api/wardrobe",
+			header:   map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"},
+			wantCode: http.StatusNoContent,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Headers": "X-Custom",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CorsMiddleware(tt.config, routes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			for name, want := range tt.wantHeader {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
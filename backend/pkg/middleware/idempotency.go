@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/pkg/response"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make retries safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodyBytes caps the request bodies read for fingerprinting. It
+// matches the largest body any route accepts, a wardrobe import.
+const maxIdempotentBodyBytes = 10 << 20
+
+// IdempotencyMiddleware replays the stored response when a mutating request is
+// retried with the same Idempotency-Key. It must run after AuthMiddleware,
+// since keys are scoped to the authenticated user; a key sent without one is
+// rejected rather than ignored, so the client doesn't assume retries are safe.
+func IdempotencyMiddleware(store domain.IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				response.BadRequest(w, "Idempotency-Key is too long")
+				return
+			}
+
+			user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+			if !ok {
+				response.BadRequest(w, "Idempotency-Key requires an authenticated request")
+				return
+			}
+
+			// Read the body so it can be fingerprinted, then hand it on unchanged
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				response.Error(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+				return
+			}
+			if err != nil {
+				response.BadRequest(w, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := requestFingerprint(r, body)
+			existing, reserved, err := store.Reserve(user.ID, key, fingerprint, ttl)
+			if err != nil {
+				response.InternalServerError(w, "Failed to check idempotency key")
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					response.Error(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				case existing.Pending:
+					response.Error(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			// Capture the response so retries can be answered with it
+			rec := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				// Server errors and panics aren't stored, so the client can retry
+				if !completed {
+					store.Release(user.ID, key)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode < http.StatusInternalServerError {
+				if err := store.Complete(user.ID, key, rec.statusCode, rec.Header().Clone(), rec.body.Bytes()); err == nil {
+					completed = true
+				}
+			}
+		})
+	}
+}
+
+// isMutating reports whether requests with the method change state
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies a request by its method, target and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay writes a stored response back to the client
+func replay(w http.ResponseWriter, record *domain.IdempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// recordingWriter passes a response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// WriteHeader captures the status code
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write captures the body
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered response to the client
+func (rw *recordingWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/pkg/response"
+)
+
+// imageURLFields are the JSON fields holding image URLs: a clothing item's
+// imageUrls, an outfit's imageUrl and a user's picture
+var imageURLFields = map[string]bool{
+	"imageUrls": true,
+	"imageUrl":  true,
+	"picture":   true,
+}
+
+// ImageURLMiddleware lets clients load their images from the private image
+// store. It must run after AuthMiddleware. In JSON responses, the image fields
+// of items, outfits and profiles are given signed URLs, and only for images
+// stored under the signed-in user's own prefix; other URLs are left as they
+// are. Signed URLs sent back in JSON request bodies, as when a client saves an
+// item it loaded, are turned back into the image's URL before being stored.
+// Account export archives carry copies of the images themselves, so the URLs
+// in them aren't signed.
+func ImageURLMiddleware(store domain.ImageStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(domain.ContextKeyUser).(*domain.User)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Body != nil && isMutating(r.Method) && isJSON(r.Header.Get("Content-Type")) {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					response.Error(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+					return
+				}
+				if err != nil {
+					response.BadRequest(w, "Failed to read request body")
+					return
+				}
+				body = rewriteImageURLs(body, func(url string) string {
+					return unsignImageURL(store, url)
+				})
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			iw := &imageURLWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(iw, r)
+			if !iw.buffering {
+				return
+			}
+
+			body := rewriteImageURLs(iw.buf.Bytes(), func(url string) string {
+				signed, err := store.SignURL(user.ID, url)
+				if err != nil {
+					return url
+				}
+				return signed
+			})
+			w.Header().Del("Content-Length")
+			w.WriteHeader(iw.statusCode)
+			w.Write(body)
+		})
+	}
+}
+
+// unsignImageURL strips the signature from a signed URL of a stored image
+func unsignImageURL(store domain.ImageStore, url string) string {
+	i := strings.IndexByte(url, '?')
+	if i < 0 {
+		return url
+	}
+	// Nothing is owned by an empty ID, so this only checks the image is stored
+	if _, err := store.SignURL("", url); errors.Is(err, domain.ErrImageNotStored) {
+		return url
+	}
+	return url[:i]
+}
+
+// rewriteImageURLs applies fn to the URLs in the image fields of a JSON body.
+// The body is returned as it was if it isn't JSON or no URL changed.
+func rewriteImageURLs(body []byte, fn func(url string) string) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return body
+	}
+	if !rewriteImageFields(document, fn) {
+		return body
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(document); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+// rewriteImageFields walks a decoded JSON value, applying fn to the URLs in
+// image fields, and reports whether any changed
+func rewriteImageFields(value interface{}, fn func(url string) string) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if !imageURLFields[key] {
+				changed = rewriteImageFields(field, fn) || changed
+				continue
+			}
+			switch f := field.(type) {
+			case string:
+				if rewritten := fn(f); rewritten != f {
+					v[key] = rewritten
+					changed = true
+				}
+			case []interface{}:
+				for i, element := range f {
+					if url, ok := element.(string); ok {
+						if rewritten := fn(url); rewritten != url {
+							f[i] = rewritten
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			changed = rewriteImageFields(element, fn) || changed
+		}
+	}
+	return changed
+}
+
+// isJSON reports whether a content type is JSON, including JSON merge patches
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// imageURLWriter holds back JSON responses so their image URLs can be signed;
+// anything else is passed straight through
+type imageURLWriter struct {
+	http.ResponseWriter
+	buf        bytes.Buffer
+	statusCode int
+	started    bool
+	buffering  bool
+}
+
+func (w *imageURLWriter) WriteHeader(statusCode int) {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.statusCode = statusCode
+
+	if isJSON(w.Header().Get("Content-Type")) {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *imageURLWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
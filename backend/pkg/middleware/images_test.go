@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lilo/backend/internal/domain"
+)
+
+const testImageBucket = "https://images.example.com/"
+
+// fakeImageStore stores images under testImageBucket, keyed by owner ID
+type fakeImageStore struct{}
+
+func (fakeImageStore) Fetch(ownerID, url string) ([]byte, string, error) {
+	return nil, "", domain.ErrImageNotStored
+}
+
+func (fakeImageStore) Delete(ownerID, url string) error {
+	return domain.ErrImageNotStored
+}
+
+func (fakeImageStore) SignURL(ownerID, url string) (string, error) {
+	if !strings.HasPrefix(url, testImageBucket) {
+		return "", domain.ErrImageNotStored
+	}
+	url, _, _ = strings.Cut(url, "?")
+	if ownerID == "" || !strings.HasPrefix(url, testImageBucket+ownerID+"/") {
+		return "", domain.ErrImageNotOwned
+	}
+	return url + "?signature=" + ownerID, nil
+}
+
+func TestImageURLMiddlewareSignsResponses(t *testing.T) {
+	own := testImageBucket + "user-1/shirt.jpg"
+	other := testImageBucket + "user-2/shirt.jpg"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "own images are signed",
+			contentType: "application/json",
+			body:        `{"imageUrls":["` + own + `"],"picture":"` + own + `"}`,
+			want:        `{"imageUrls":["` + own + `?signature=user-1"],"picture":"` + own + `?signature=user-1"}` + "\n",
+		},
+		{
+			name:        "nested outfit images are signed",
+			contentType: "application/json",
+			body:        `{"data":[{"imageUrl":"` + own + `","wearCount":3}]}`,
+			want:        `{"data":[{"imageUrl":"` + own + `?signature=user-1","wearCount":3}]}` + "\n",
+		},
+		{
+			name:        "another user's images are left alone",
+			contentType: "application/json",
+			body:        `{"imageUrls":["` + other + `"]}`,
+			want:        `{"imageUrls":["` + other + `"]}`,
+		},
+		{
+			name:        "external images are left alone",
+			contentType: "application/json",
+			body:        `{"picture":"https://avatars.example.org/me.png"}`,
+			want:        `{"picture":"https://avatars.example.org/me.png"}`,
+		},
+		{
+			name:        "other fields holding image URLs are left alone",
+			contentType: "application/json",
+			body:        `{"notes":"` + own + `"}`,
+			want:        `{"notes":"` + own + `"}`,
+		},
+		{
+			name:        "responses that aren't JSON pass through",
+			contentType: "text/csv",
+			body:        "imageUrl\n" + own + "\n",
+			want:        "imageUrl\n" + own + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ImageURLMiddleware(fakeImageStore{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, tt.body)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/wardrobe", nil)
+			req = req.WithContext(context.WithValue(req.Context(), domain.ContextKeyUser, &domain.User{ID: "user-1"}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageURLMiddlewareUnsignsRequests(t *testing.T) {
+	own := testImageBucket + "user-1/shirt.jpg"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "signed URLs are stored without their signature",
+			contentType: "application/json",
+			body:        `{"imageUrls":["` + own + `?signature=user-1"]}`,
+			want:        `{"imageUrls":["` + own + `"]}` + "\n",
+		},
+		{
+			name:        "merge patches are unsigned too",
+			contentType: "application/merge-patch+json",
+			body:        `{"imageUrl":"` + own + `?signature=user-1"}`,
+			want:        `{"imageUrl":"` + own + `"}` + "\n",
+		},
+		{
+			name:        "external URLs keep their query",
+			contentType: "application/json",
+			body:        `{"picture":"https://avatars.example.org/me.png?size=64"}`,
+			want:        `{"picture":"https://avatars.example.org/me.png?size=64"}`,
+		},
+		{
+			name:        "bodies that aren't JSON pass through",
+			contentType: "text/plain",
+			body:        own + "?signature=user-1",
+			want:        own + "?signature=user-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := ImageURLMiddleware(fakeImageStore{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = string(body)
+			}))
+			req := httptest.NewRequest(http.MethodPut, "/api/wardrobe/item-1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(context.WithValue(req.Context(), domain.ContextKeyUser, &domain.User{ID: "user-1"}))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults for caching a JWKS document
+const (
+	DefaultJWKSCacheTTL        = 10 * time.Minute
+	DefaultJWKSRefreshInterval = 30 * time.Second
+)
+
+// maxJWKSBytes caps the size of a fetched JWKS document
+const maxJWKSBytes = 1 << 20
+
+// ErrUnknownSigningKey is returned for a token signed with a key that isn't in
+// the key set, even after refreshing it
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// JWKSFetcher retrieves a JSON Web Key Set document. The HTTP fetcher reads it
+// from the auth provider; tests can return a local key set instead.
+type JWKSFetcher interface {
+	FetchJWKS(ctx context.Context) ([]byte, error)
+}
+
+// HTTPJWKSFetcher fetches a JWKS document from a URL
+type HTTPJWKSFetcher struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPJWKSFetcher creates a fetcher for the JWKS document at url
+func NewHTTPJWKSFetcher(url string) *HTTPJWKSFetcher {
+	return &HTTPJWKSFetcher{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// FetchJWKS downloads the key set
+func (f *HTTPJWKSFetcher) FetchJWKS(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching %s: %s", f.URL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+}
+
+// signingKey is a public key from a key set, with the algorithm it's
+// restricted to, if any
+type signingKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// JWKSCache keeps the keys of a JWKS document in memory. The set is refetched
+// once it's older than the TTL, and early when a token names a key it doesn't
+// have, which is how rotated keys are picked up. Refetches are spaced at least
+// the refresh interval apart so tokens with made-up key IDs can't flood the
+// provider, and if one fails the keys already held keep working.
+type JWKSCache struct {
+	fetcher         JWKSFetcher
+	ttl             time.Duration
+	refreshInterval time.Duration
+
+	keys        map[string]signingKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	mu          sync.RWMutex
+	refreshMu   sync.Mutex // one refetch at a time
+}
+
+// NewJWKSCache creates a key cache over a fetcher. Zero durations use the defaults.
+func NewJWKSCache(fetcher JWKSFetcher, ttl, refreshInterval time.Duration) *JWKSCache {
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+	return &JWKSCache{
+		fetcher:         fetcher,
+		ttl:             ttl,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]signingKey),
+	}
+}
+
+// Key returns the public key with the given key ID for verifying a token
+// signed with alg. A token without a key ID is accepted only when the set
+// holds a single key.
+func (c *JWKSCache) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	key, found, fresh := c.lookup(kid)
+	if !found || !fresh {
+		err := c.refresh(ctx, !found)
+		if key, found, _ = c.lookup(kid); !found {
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSigningKey, kid)
+		}
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("key %q is for %s, not %s", kid, key.alg, alg)
+	}
+	return key.key, nil
+}
+
+func (c *JWKSCache) lookup(kid string) (signingKey, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fresh := time.Since(c.fetchedAt) < c.ttl
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true, fresh
+		}
+	}
+	key, found := c.keys[kid]
+	return key, found, fresh
+}
+
+// refresh refetches the key set. Unless forced by an unknown key ID, a set
+// another request refreshed while this one waited is left alone.
+func (c *JWKSCache) refresh(ctx context.Context, force bool) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	fresh := time.Since(c.fetchedAt) < c.ttl
+	recent := time.Since(c.lastAttempt) < c.refreshInterval
+	c.mu.RUnlock()
+	if (fresh && !force) || recent {
+		return nil
+	}
+
+	data, err := c.fetcher.FetchJWKS(ctx)
+	c.mu.Lock()
+	c.lastAttempt = time.Now()
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+// jwk is a single JSON Web Key, with the members used for RSA, EC and OKP keys
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS reads the signing keys from a JWKS document, keyed by key ID.
+// Encryption keys, key types it doesn't support and malformed keys are
+// skipped, so one bad entry doesn't lock out tokens signed with the others.
+func parseJWKS(data []byte) (map[string]signingKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+
+	keys := make(map[string]signingKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err == nil && key != nil {
+			keys[k.Kid] = signingKey{key: key, alg: k.Alg}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS document has no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key, or returns nil for key types that aren't supported
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("not base64url encoded")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
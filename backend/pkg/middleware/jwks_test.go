@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeJWKSFetcher serves a key set held in memory and counts fetches
+type fakeJWKSFetcher struct {
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+}
+
+func (f *fakeJWKSFetcher) FetchJWKS(ctx context.Context) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	return json.Marshal(map[string]interface{}{"keys": f.keys})
+}
+
+func (f *fakeJWKSFetcher) setKeys(keys ...map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+func (f *fakeJWKSFetcher) fetchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetches
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": key.Curve.Params().Name,
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func ed25519JWK(kid string, key ed25519.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"kid": kid,
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// signWithKey signs a token for a Supabase user with an asymmetric key
+func signWithKey(t *testing.T, method jwt.SigningMethod, kid string, key crypto.Signer) string {
+	t.Helper()
+	claims := &SupabaseJWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Sub: "supabase-1",
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func TestTokenVerifierWithJWKS(t *testing.T) {
+	ecKey := newECKey(t)
+	otherKey := newECKey(t)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	restricted := ecJWK("restricted", ecKey)
+	restricted["alg"] = "ES384"
+	encryption := ecJWK("encryption", ecKey)
+	encryption["use"] = "enc"
+
+	fetcher := &fakeJWKSFetcher{}
+	fetcher.setKeys(ecJWK("ec", ecKey), ed25519JWK("ed", edKey), restricted, encryption)
+	verifier := NewTokenVerifier(TokenVerifierConfig{Keys: NewJWKSCache(fetcher, 0, 0)})
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &SupabaseJWTClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Sub:              "supabase-1",
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "ES256 key from the set", token: signWithKey(t, jwt.SigningMethodES256, "ec", ecKey)},
+		{name: "EdDSA key from the set", token: signWithKey(t, jwt.SigningMethodEdDSA, "ed", edKey)},
+		{name: "key not in the set", token: signWithKey(t, jwt.SigningMethodES256, "other", otherKey), wantErr: true},
+		{name: "known key ID signed by another key", token: signWithKey(t, jwt.SigningMethodES256, "ec", otherKey), wantErr: true},
+		{name: "key restricted to another algorithm", token: signWithKey(t, jwt.SigningMethodES256, "restricted", ecKey), wantErr: true},
+		{name: "encryption key", token: signWithKey(t, jwt.SigningMethodES256, "encryption", ecKey), wantErr: true},
+		{name: "HMAC token without a shared secret", token: hmacToken, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Verify succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Sub != "supabase-1" {
+				t.Errorf("sub = %q, want %q", claims.Sub, "supabase-1")
+			}
+		})
+	}
+}
+
+func TestJWKSCacheRefetches(t *testing.T) {
+	oldKey := newECKey(t)
+	newKey := newECKey(t)
+
+	tests := []struct {
+		name            string
+		refreshInterval time.Duration
+		token           string
+		wantErr         bool
+		wantFetches     int
+	}{
+		{
+			name:            "rotated key is picked up",
+			refreshInterval: time.Nanosecond,
+			token:           signWithKey(t, jwt.SigningMethodES256, "new", newKey),
+			wantFetches:     2,
+		},
+		{
+			name:            "unknown key IDs don't refetch within the interval",
+			refreshInterval: time.Hour,
+			token:           signWithKey(t, jwt.SigningMethodES256, "made-up", newKey),
+			wantErr:         true,
+			wantFetches:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fakeJWKSFetcher{}
+			fetcher.setKeys(ecJWK("old", oldKey))
+			verifier := NewTokenVerifier(TokenVerifierConfig{Keys: NewJWKSCache(fetcher, 0, tt.refreshInterval)})
+
+			if _, err := verifier.Verify(context.Background(), signWithKey(t, jwt.SigningMethodES256, "old", oldKey)); err != nil {
+				t.Fatalf("Verify with the old key: %v", err)
+			}
+			time.Sleep(time.Millisecond)
+			fetcher.setKeys(ecJWK("old", oldKey), ecJWK("new", newKey))
+
+			_, err := verifier.Verify(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := fetcher.fetchCount(); got != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/internal/repository"
+)
+
+func rateLimitedRequest(remoteAddr, userID string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/wardrobe", nil)
+	req.RemoteAddr = remoteAddr
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), domain.ContextKeyUser, &domain.User{ID: userID}))
+	}
+	return req
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	type step struct {
+		remoteAddr string
+		userID     string
+		wantCode   int
+	}
+
+	tests := []struct {
+		name  string
+		limit domain.RateLimit
+		steps []step
+	}{
+		{
+			name:  "requests over the limit are refused",
+			limit: domain.RateLimit{Requests: 2, Window: time.Minute},
+			steps: []step{
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1001", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1002", wantCode: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:  "each IP address has its own bucket",
+			limit: domain.RateLimit{Requests: 1, Window: time.Minute},
+			steps: []step{
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.2:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:  "a user's devices share a bucket",
+			limit: domain.RateLimit{Requests: 1, Window: time.Minute},
+			steps: []step{
+				{remoteAddr: "10.0.0.1:1000", userID: "user-1", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.2:1000", userID: "user-1", wantCode: http.StatusTooManyRequests},
+				{remoteAddr: "10.0.0.2:1000", userID: "user-2", wantCode: http.StatusOK},
+			},
+		},
+		{
+			name:  "signed-in users don't use the IP address bucket",
+			limit: domain.RateLimit{Requests: 1, Window: time.Minute},
+			steps: []step{
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1000", userID: "user-1", wantCode: http.StatusOK},
+			},
+		},
+		{
+			name:  "a zero limit disables limiting",
+			limit: domain.RateLimit{},
+			steps: []step{
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1000", wantCode: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RateLimitMiddleware(repository.NewRateLimitStore(), "test", tt.limit)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			for i, step := range tt.steps {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, rateLimitedRequest(step.remoteAddr, step.userID))
+				if rec.Code != step.wantCode {
+					t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, step.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddlewareHeaders(t *testing.T) {
+	store := repository.NewRateLimitStore()
+	limit := domain.RateLimit{Requests: 1, Window: 90 * time.Second}
+	handler := RateLimitMiddleware(store, "test", limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		wantCode   int
+		wantHeader map[string]string
+	}{
+		{
+			name:     "allowed",
+			wantCode: http.StatusOK,
+			wantHeader: map[string]string{
+				"RateLimit-Policy":    "1;w=90",
+				"RateLimit-Limit":     "1",
+				"RateLimit-Remaining": "0",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:     "refused",
+			wantCode: http.StatusTooManyRequests,
+			wantHeader: map[string]string{
+				"RateLimit-Policy":    "1;w=90",
+				"RateLimit-Limit":     "1",
+				"RateLimit-Remaining": "0",
+			},
+		},
+	}
+
+	// The cases run in order against the same bucket
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, rateLimitedRequest("10.0.0.1:1000", ""))
+		if rec.Code != tt.wantCode {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantCode)
+		}
+		for name, want := range tt.wantHeader {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+		if tt.wantCode == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: Retry-After is missing", tt.name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lilo/backend/internal/domain"
+	"github.com/lilo/backend/pkg/response"
+)
+
+// Standard Webhooks headers, as sent by Supabase
+const (
+	WebhookIDHeader        = "webhook-id"
+	WebhookTimestampHeader = "webhook-timestamp"
+	WebhookSignatureHeader = "webhook-signature"
+)
+
+// WebhookTolerance is how far a webhook's timestamp may be from now, which
+// bounds how long a captured request can be replayed
+const WebhookTolerance = 5 * time.Minute
+
+// maxWebhookBytes caps the size of a webhook body
+const maxWebhookBytes = 1 << 20
+
+// webhookStoreScope keys webhook message IDs in the idempotency store, in
+// place of a user ID
+const webhookStoreScope = "webhook"
+
+// ParseWebhookSecret decodes a Standard Webhooks secret, given with or without
+// its "v1," and "whsec_" prefixes
+func ParseWebhookSecret(secret string) ([]byte, error) {
+	secret = strings.TrimPrefix(strings.TrimSpace(secret), "v1,")
+	secret = strings.TrimPrefix(secret, "whsec_")
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, errors.New("webhook secret must be base64, optionally prefixed with whsec_")
+	}
+	return key, nil
+}
+
+// WebhookSignatureMiddleware rejects requests that aren't signed with the
+// shared secret following the Standard Webhooks scheme: an HMAC-SHA256 of the
+// message ID, timestamp and body, sent base64 encoded as "v1,<signature>".
+// Each message ID is accepted once while its timestamp is in the window, so a
+// captured request can't be replayed; a delivery that fails with a server
+// error frees its ID for the sender's retry.
+func WebhookSignatureMiddleware(secret []byte, store domain.IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(WebhookIDHeader)
+			timestamp := r.Header.Get(WebhookTimestampHeader)
+			signatures := r.Header.Get(WebhookSignatureHeader)
+			if id == "" || timestamp == "" || signatures == "" {
+				response.Unauthorized(w, "Missing webhook signature headers")
+				return
+			}
+
+			// Check the timestamp before the signature, so stale requests are
+			// turned away cheaply
+			seconds, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				response.Unauthorized(w, "Invalid webhook timestamp")
+				return
+			}
+			age := time.Since(time.Unix(seconds, 0))
+			if age > WebhookTolerance || age < -WebhookTolerance {
+				response.Unauthorized(w, "Webhook timestamp is outside the allowed window")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+			if err != nil {
+				response.BadRequest(w, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(id + "." + timestamp + "."))
+			mac.Write(body)
+			expected := mac.Sum(nil)
+
+			// Several signatures may be sent while a secret is being rotated
+			for _, candidate := range strings.Fields(signatures) {
+				version, encoded, ok := strings.Cut(candidate, ",")
+				if !ok || version != "v1" {
+					continue
+				}
+				signature, err := base64.StdEncoding.DecodeString(encoded)
+				if err == nil && hmac.Equal(signature, expected) {
+					serveOnce(store, id, expected, w, r, next)
+					return
+				}
+			}
+			response.Unauthorized(w, "Invalid webhook signature")
+		})
+	}
+}
+
+// serveOnce passes a verified webhook on unless its message ID has been seen.
+// IDs are kept for twice the tolerance, since a timestamp is accepted from
+// WebhookTolerance before it until WebhookTolerance after.
+func serveOnce(store domain.IdempotencyStore, id string, signature []byte, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	_, reserved, err := store.Reserve(webhookStoreScope, id, hex.EncodeToString(signature), 2*WebhookTolerance)
+	if err != nil {
+		response.InternalServerError(w, "Failed to check webhook ID")
+		return
+	}
+	if !reserved {
+		response.Error(w, http.StatusConflict, "Webhook was already received")
+		return
+	}
+
+	rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	completed := false
+	defer func() {
+		if !completed {
+			store.Release(webhookStoreScope, id)
+		}
+	}()
+
+	next.ServeHTTP(rw, r)
+
+	if rw.statusCode < http.StatusInternalServerError {
+		if err := store.Complete(webhookStoreScope, id, rw.statusCode, nil, nil); err == nil {
+			completed = true
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lilo/backend/internal/repository"
+)
+
+var testWebhookSecret = []byte("webhook-secret")
+
+// webhookSignature signs a message the way Standard Webhooks senders do
+func webhookSignature(secret []byte, id, timestamp, body string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + timestamp + "." + body))
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func webhookRequest(id, timestamp, signature, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/supabase/auth", strings.NewReader(body))
+	if id != "" {
+		req.Header.Set(WebhookIDHeader, id)
+	}
+	if timestamp != "" {
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+	}
+	if signature != "" {
+		req.Header.Set(WebhookSignatureHeader, signature)
+	}
+	return req
+}
+
+func TestParseWebhookSecret(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(testWebhookSecret)
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "bare", secret: encoded},
+		{name: "whsec prefix", secret: "whsec_" + encoded},
+		{name: "versioned", secret: "v1,whsec_" + encoded},
+		{name: "not base64", secret: "whsec_not base64!", wantErr: true},
+		{name: "empty", secret: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseWebhookSecret(tt.secret)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseWebhookSecret(%q) succeeded, want an error", tt.secret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhookSecret(%q): %v", tt.secret, err)
+			}
+			if string(key) != string(testWebhookSecret) {
+				t.Errorf("key = %q, want %q", key, testWebhookSecret)
+			}
+		})
+	}
+}
+
+func TestWebhookSignatureMiddleware(t *testing.T) {
+	body := `{"type":"INSERT"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-2*WebhookTolerance).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(2*WebhookTolerance).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantCode int
+	}{
+		{
+			name:     "valid signature",
+			req:      webhookRequest("msg_1", now, webhookSignature(testWebhookSecret, "msg_1", now, body), body),
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "one of several signatures matches",
+			req: webhookRequest("msg_2", now,
+				webhookSignature([]byte("old-secret"), "msg_2", now, body)+" "+webhookSignature(testWebhookSecret, "msg_2", now, body), body),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "missing headers",
+			req:      webhookRequest("", "", "", body),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong secret",
+			req:      webhookRequest("msg_3", now, webhookSignature([]byte("other"), "msg_3", now, body), body),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "body changed after signing",
+			req:      webhookRequest("msg_4", now, webhookSignature(testWebhookSecret, "msg_4", now, body), `{"type":"DELETE"}`),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "unknown signature version",
+			req:      webhookRequest("msg_5", now, "v2,"+strings.TrimPrefix(webhookSignature(testWebhookSecret, "msg_5", now, body), "v1,"), body),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "stale timestamp",
+			req:      webhookRequest("msg_6", stale, webhookSignature(testWebhookSecret, "msg_6", stale, body), body),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "future timestamp",
+			req:      webhookRequest("msg_7", future, webhookSignature(testWebhookSecret, "msg_7", future, body), body),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "malformed timestamp",
+			req:      webhookRequest("msg_8", "yesterday", webhookSignature(testWebhookSecret, "msg_8", "yesterday", body), body),
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := WebhookSignatureMiddleware(testWebhookSecret, repository.NewIdempotencyStore())(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhookSignatureMiddlewareRejectsReplays(t *testing.T) {
+	body := `{"type":"UPDATE"}`
+	status := http.StatusOK
+	handler := WebhookSignatureMiddleware(testWebhookSecret, repository.NewIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+	// A delivery that fails can be retried; one that succeeded can't be replayed
+	steps := []struct {
+		handlerStatus int
+		wantCode      int
+	}{
+		{handlerStatus: http.StatusInternalServerError, wantCode: http.StatusInternalServerError},
+		{handlerStatus: http.StatusOK, wantCode: http.StatusOK},
+		{handlerStatus: http.StatusOK, wantCode: http.StatusConflict},
+	}
+	for i, step := range steps {
+		status = step.handlerStatus
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, webhookRequest("msg_replayed", timestamp, webhookSignature(testWebhookSecret, "msg_replayed", timestamp, body), body))
+		if rec.Code != step.wantCode {
+			t.Fatalf("delivery %d: status = %d, want %d", i+1, rec.Code, step.wantCode)
+		}
+	}
+}